@@ -0,0 +1,105 @@
+package gifhub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Activity is the share of the contributions of a GitHub user on a year by kind, in percent, see Fetch
+type Activity struct {
+	Handle, Year                      string
+	Commits, Issues, Prs, CodeReviews int // percentages of the contributions
+	Total                             int // contributions of the year, only counted by the graphql backend
+}
+
+// exportActivity returns the public shape of a
+func exportActivity(a activity) Activity {
+	return Activity{a.Handle, a.Year, a.Commits, a.Issues, a.Prs, a.CodeReviews, a.Total}
+}
+
+// activity returns the activity drawn for a
+func (a Activity) activity() activity {
+	return activity{Handle: a.Handle, Year: a.Year, Commits: a.Commits, Issues: a.Issues, Prs: a.Prs, CodeReviews: a.CodeReviews, Total: a.Total}
+}
+
+// FetchOptions configure Fetch, the zero value scrapes the profile page without a token
+type FetchOptions struct {
+	Backend string       // html or graphql, html when empty
+	Token   string       // a GitHub personal access token, required by the graphql backend
+	Client  *http.Client // nil for a client timing out after 30 seconds
+	Retries int          // of the requests failing with a network error, a 429 or a 5xx status
+	Host    string       // of a GitHub Enterprise instance, such as github.example.com, GitHub when empty
+}
+
+// Fetch returns the activity of a GitHub user on a year, such as 2020, or range of months, such as 2021-03:2021-09
+func Fetch(handle, year string, opts FetchOptions) (Activity, error) {
+	if opts.Retries < 0 {
+		return Activity{}, fmt.Errorf("invalid retries %d, must not be negative", opts.Retries)
+	}
+	fetchOpts := fetchOptions{
+		MaxBytes:      5 << 20,
+		Backoff:       &backoff{},
+		MarkupVersion: "auto",
+		Client:        opts.Client,
+		Token:         opts.Token,
+		Retries:       opts.Retries,
+	}
+	if opts.Host != "" {
+		baseURL, err := parseHost(opts.Host)
+		if err != nil {
+			return Activity{}, err
+		}
+		if baseURL != githubURL {
+			fetchOpts.BaseURL = baseURL
+		}
+	}
+	if fetchOpts.Client == nil {
+		fetchOpts.Client = newClient(true, 30*time.Second)
+	}
+	fetcher, err := newActivityFetcher(opts.Backend, fetchOpts)
+	if err != nil {
+		return Activity{}, err
+	}
+	act, err := fetcher.Fetch(handle, year)
+	if err != nil {
+		return Activity{}, err
+	}
+	return exportActivity(act), nil
+}
+
+// Render draws the radar chart of act as a frame of the GIF, width pixels wide, 0 for the default 500
+func Render(act Activity, width int) (image.Image, error) {
+	l := layout{Width: defaultWidth}
+	if width != 0 {
+		l.Width = float64(width)
+	}
+	if l.Width < minWidth {
+		return nil, fmt.Errorf("invalid width %d, must be at least %d", width, minWidth)
+	}
+	font, err := loadFont("")
+	if err != nil {
+		return nil, err
+	}
+	s := withFonts(scaleStyle(defaultStyle(), l.Width/defaultWidth), font)
+	a := act.activity()
+	return img(graph{Data: a, Coords: coordinates(a, l)}, s), nil
+}
+
+// Encode bundles the frames into a GIF written to w, looping forever and showing every frame for delay hundredths of a second
+func Encode(w io.Writer, frames []image.Image, delay int) error {
+	if delay < 0 {
+		return errors.New("invalid delay, must not be negative")
+	}
+	return encodeGIF(context.Background(), w, frames, gifOptions{
+		FirstDelay: delay,
+		Delay:      delay,
+		FinalDelay: delay,
+		Palette:    palette.Plan9,
+	})
+}
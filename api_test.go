@@ -0,0 +1,65 @@
+package gifhub
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"net/http"
+	"testing"
+)
+
+func TestFetchRenderEncode(t *testing.T) {
+	client := &http.Client{Transport: roundTripper{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, overviewFixture)
+	})}}
+	act, err := Fetch("octocat", "2020", FetchOptions{Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Activity is a type of its own, comparable as it holds no slice
+	want := Activity{Handle: "octocat", Year: "2020", Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}
+	if act != want {
+		t.Errorf("expected %+v, got %+v", want, act)
+	}
+
+	// an importer builds its own activity as well
+	var frames []image.Image
+	for _, a := range []Activity{act, {Handle: "octocat", Year: "2021", Commits: 100}} {
+		frame, err := Render(a, 300)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := frame.Bounds().Dx(); w != 300 {
+			t.Errorf("%s: expected a frame 300 pixels wide, got %d", a.Year, w)
+		}
+		frames = append(frames, frame)
+	}
+	if _, err := Render(act, 50); err == nil {
+		t.Error("expected a frame narrower than the minimum width to fail")
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, frames, 100); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != len(frames) || anim.Delay[0] != 100 || anim.LoopCount != 0 {
+		t.Errorf("expected %d frames of 100 looping forever, got %d frames, the delays %v and the loop count %d",
+			len(frames), len(anim.Image), anim.Delay, anim.LoopCount)
+	}
+	if err := Encode(&buf, frames, -1); err == nil {
+		t.Error("expected a negative delay to fail")
+	}
+}
+
+func TestFetchInvalid(t *testing.T) {
+	for _, opts := range []FetchOptions{{Backend: "rest"}, {Retries: -1}, {Host: "ftp://github.example.com"}} {
+		if _, err := Fetch("octocat", "2020", opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
@@ -0,0 +1,3240 @@
+package gifhub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"github.com/fogleman/gg"
+)
+
+// activity contains GitHub's tracked user activity percentages for a given year
+type activity struct {
+	Handle, Year                      string
+	Commits, Issues, Prs, CodeReviews int
+	Streak                            int   // longest run of days with contributions, if scraped, or unknownStreak
+	Total                             int   // contributions of the year, only counted by the GraphQL backend
+	Days                              []day // contributions calendar, only scraped for --chart calendar
+}
+
+// coords contains the X,Y coordinates of the activities in an activity graph.
+// Aswell as measurements used to calculate margins and offsets
+type coords struct {
+	W, H, Mid, Factor, AxisMargin,
+	CodeReviewY, IssuesX, PrsY, CommitsX float64
+	GraphH, MidY float64   // height and vertical center of the chart, which takes the height of the canvas but for the label strip
+	Ticks        []float64 // distance from the origin of every tickPercents value along the vertical axis, following cappedDelta
+}
+
+// tickPercents are the values marked along the code review axis by --scale-ticks
+var tickPercents = []int{25, 50, 75, 100}
+
+// style contains the style attributes of the graph such as font, colors, and size of markers
+type style struct {
+	LabelColor, ValueColor, AxisColor, PolyColor color.Color
+	LabelFont, ValueFont, TickFont               font.Face
+	MarkerRadius                                 float64
+	FormatValue                                  valueFormatter
+	LabelPos                                     string // bottom, top or overlay
+	ShowStreak                                   bool
+	OriginColor                                  color.Color // nil to not mark the origin
+	Chart                                        string      // radar or calendar
+	NoLabels                                     bool
+	BgGradient                                   []color.Color // top and bottom colors, nil for a solid background
+	Smooth                                       bool          // draw the polygon as a curve through its vertices
+	LabelTemplate                                string        // caption of the frame, see expandLabel
+	HighlightColor                               color.Color   // nil to not highlight the largest metric
+	AxisExtent                                   string        // full, to-vertex or edge
+	UprightLabels                                bool          // keep the labels of a rotated chart upright
+	Scale                                        float64       // of the fonts, relative to the default 500px wide canvas
+	ScaleTicks                                   bool          // mark the distance of tickPercents along the code review axis
+	Grid                                         bool          // draw a ring through tickPercents of every axis underneath the polygon
+	Legend                                       bool          // list the metrics and values in a corner instead of along the axes
+	Rewind                                       bool          // mark the frames as played from the newest year to the oldest
+	DitherFill                                   bool          // dither the translucent fill of overlaid polygons instead of blending it
+	Transparent                                  bool          // leave the background transparent instead of white
+	LabelSize, ValueSize                         float64       // of the fonts in points, 0 for the default sizes times Scale
+}
+
+// labelPositions are the valid placements of the handle and year labels
+var labelPositions = []string{"bottom", "top", "overlay"}
+
+// axisExtents are the valid ends of the axes: the full axis length, the vertices, or the edges of the graph
+var axisExtents = []string{"full", "to-vertex", "edge"}
+
+// formats are the valid output encodings
+var formats = []string{"gif", "webp", "png", "json"}
+
+// charts are the valid visualizations of the activity
+var charts = []string{"radar", "calendar"}
+
+// scraper returns the activity of a user on a given year, the wrappers such as withCache decorate it
+type scraper func(handle, year string) (activity, error)
+
+// Fetch implements activityFetcher, so that a decorated scraper is one
+func (s scraper) Fetch(handle, year string) (activity, error) {
+	return s(handle, year)
+}
+
+// valueFormatter formats the value drawn next to the label of a metric.
+// raw is the absolute amount of contributions (0 if unknown) and pct its percentage
+type valueFormatter func(metric string, raw, pct int) string
+
+// graph contains all information to build the graph of a user's activity for a given year
+type graph struct {
+	Data   activity
+	Coords coords
+	// Prev is the activity of the previous year, nil when there is none to compare against
+	Prev *activity
+	// Series are the activities of several users overlaid instead of Data's, see overlayGraphs
+	Series []series
+	// Rotation is the angle in radians the chart is turned by about its center, see spinImgs
+	Rotation float64
+	// Baseline is the polygon of --baseline-year drawn faintly underneath, nil for none
+	Baseline *coords
+}
+
+// activityImage contains the image encoding of an activity graph
+// as well as the year of the graph for identification and sorting
+type activityImage struct {
+	Img  image.Image
+	Year string
+	Err  error // of the rendering, the image is nil when set
+}
+
+// GenerateOptions configure Generate, with a field for every flag of the command line
+// the zero value of most fields is not valid, start from DefaultGenerateOptions
+type GenerateOptions struct {
+	Handles []string // GitHub-usernames, several are overlaid on every frame
+	Sample  bool     // draw the built-in activity of sample.gif instead of the Handles'
+
+	Years                   string // 2016,2017, ranges 2016-2019, a file @years.txt or all
+	SinceJoin               bool   // scrape every year since the user joined GitHub instead of Years
+	Reverse, Rewind         bool
+	HandleCaseNormalization bool
+	Concurrency             int // years scraped at once, 1 scrapes them in order, 0 for no limit
+	MergeYears              bool
+
+	OutDir    string // output directory, or file if it ends in the extension
+	Output    string // output file, instead of <handle>.gif in OutDir when set
+	RunFolder bool
+	Format    string // gif, webp, png or json
+	Extension string // of the output file regardless of its encoding, gif or webp following Format when empty
+	JSON      string // file the activities are also written to, - for the standard output
+	Stdout    bool
+	Palette   string // plan9, websafe or adaptive
+
+	Delay              int     // transition delay in hundredths of a second
+	FPS                float64 // frame rate replacing Delay, 0 to keep Delay
+	FinalDelay         int     // of the final frame, 0 for Delay
+	Title              bool
+	TitleDelay         int // of the Title frame, 0 for twice Delay
+	Loop               int // -1 to play once, 0 to loop forever or the number of times to loop
+	Live               int // frames between the rebuilds of latest.gif, 0 for none
+	FramesDir          string
+	DiffFrames         bool
+	EmitFramesMetadata bool
+	Spin               int    // frames of the intro turning the first chart, 0 for none
+	SpinLabels         string // upright or rotate
+	InlineTerminal     bool
+	Boomerang          bool
+	BoomerangYears     int
+	Bounce             bool
+	Autocrop           bool
+	Padding            int
+	FrameBg            string // #RRGGBB of the Padding, the background of the graph when empty
+	CompactFrames      bool
+	RepeatLast         int
+
+	BaselineYear     string
+	CompareDiffImage string // two years 2016,2020 compared in a PNG instead of a GIF
+	MaxResponseBytes int64
+
+	Chart                          string // radar or calendar
+	LabelPos                       string // bottom, top or overlay
+	LabelTemplate                  string // caption of the frames, see expandLabel, the handle and year when empty
+	Smooth, DitherFill, ScaleTicks bool
+	Legend, Grid, NoLabels         bool
+	ShowStreak, Deltas             bool
+	BgGradient                     string // top and bottom colors #fff:#eee, a solid background when empty
+	Transparent                    bool
+	Font                           string  // TrueType file, Go Regular when empty
+	LabelSize, ValueSize           float64 // in points, 0 for the default sizes scaled with Size
+	OriginDot                      string  // #RRGGBB of the dot marking the origin, none when empty
+	AxisColor, PolyColor           string  // #RRGGBB, the theme colors when empty
+	LabelColor, ValueColor         string
+	ThemeFromProfile               bool
+	RenderTimeout                  time.Duration // 0 to not bound the rendering and encoding
+	Size                           int           // width of the chart in pixels
+	MinDelta                       float64
+	AxisExtent                     string // full, to-vertex or edge
+	SizeByTotal, HighlightMax      bool
+
+	Report, DryRun bool
+	Quiet          bool // discard the progress and the logs of the run
+	DumpCoords     bool
+	MetricsFile    string
+	EmbedSource    bool
+	ValidateOutput bool
+	Sidecar        bool
+	SidecarOptions map[string]interface{} // recorded as the options of the run by Sidecar, such as the flags of the command line
+	Reproducible   bool
+	Strict         bool
+	StrictMarkup   bool
+	SelfCheck      bool // check the scraping against the last year of the single handle, or of a well-known profile, instead
+
+	Token         string
+	Host          string // of a GitHub Enterprise instance, such as github.example.com, or github.com
+	NoCache       bool
+	CacheTTL      time.Duration
+	Retries       int
+	Timeout       time.Duration // of a request, 0 to wait indefinitely
+	NoKeepAlive   bool
+	Backend       string // html or graphql
+	MarkupVersion string // 2023, 2024 or auto
+}
+
+// DefaultGenerateOptions returns the options of the command line without any flag
+func DefaultGenerateOptions() GenerateOptions {
+	return GenerateOptions{
+		Years:            "all",
+		Concurrency:      defaultConcurrency,
+		OutDir:           "./out",
+		Format:           "gif",
+		Palette:          "plan9",
+		Delay:            100,
+		SpinLabels:       "upright",
+		BoomerangYears:   3,
+		MaxResponseBytes: 5 << 20,
+		Chart:            "radar",
+		LabelPos:         "bottom",
+		Size:             int(defaultWidth),
+		AxisExtent:       "full",
+		Host:             "github.com",
+		CacheTTL:         defaultCacheTTL,
+		Retries:          3,
+		Timeout:          30 * time.Second,
+		Backend:          "html",
+		MarkupVersion:    "auto",
+	}
+}
+
+// logger logs the progress and warnings of gifhub, apart from the standard logger of the programs using the package
+// --quiet discards its output for the duration of a run
+var logger = log.New(os.Stderr, "", log.LstdFlags)
+
+// silence discards the output of logger until the returned function restores it
+func silence() func() {
+	out := logger.Writer()
+	logger.SetOutput(ioutil.Discard)
+	return func() { logger.SetOutput(out) }
+}
+
+// Generate creates the GIF of the activities of the Handles, or the output of o.Format, as the command line does
+// ctx bounds the rendering and encoding of the frames
+func Generate(ctx context.Context, o GenerateOptions) error {
+	quiet := o.Quiet
+	if quiet {
+		// the error of the run is still returned, for the caller to report
+		defer silence()()
+	}
+
+	var stats *metrics
+	if path := o.MetricsFile; path != "" {
+		stats = &metrics{}
+		defer func() {
+			if err := writeMetricsFile(stats, path); err != nil {
+				logger.Printf("metrics file: %v\n", err)
+			}
+		}()
+	}
+
+	if o.Timeout < 0 {
+		return fmt.Errorf("invalid timeout %v, must not be negative", o.Timeout)
+	}
+	opts := fetchOptions{
+		MaxBytes:      o.MaxResponseBytes,
+		Backoff:       &backoff{},
+		StrictMarkup:  o.StrictMarkup,
+		MarkupVersion: o.MarkupVersion,
+		Metrics:       stats,
+		Client:        newClient(!o.NoKeepAlive, o.Timeout),
+		Token:         o.Token,
+		Retries:       o.Retries,
+	}
+	baseURL, err := parseHost(o.Host)
+	if err != nil {
+		return err
+	}
+	if baseURL != githubURL {
+		opts.BaseURL = baseURL
+	}
+	if opts.MaxBytes <= 0 {
+		return fmt.Errorf("invalid max response bytes %d, must be positive", opts.MaxBytes)
+	}
+	if opts.Retries < 0 {
+		return fmt.Errorf("invalid retries %d, must not be negative", opts.Retries)
+	}
+	if versions := markupVersionNames(); !contains(versions, opts.MarkupVersion) {
+		return fmt.Errorf("invalid markup version %q, must be one of %s", opts.MarkupVersion, strings.Join(versions, ","))
+	}
+	backend := o.Backend
+	switch {
+	case !contains(backends, backend):
+		return fmt.Errorf("invalid backend %q, must be one of %s", backend, strings.Join(backends, ","))
+	case backend == "graphql" && opts.Token == "":
+		return errors.New("--backend graphql requires --token or GIFHUB_TOKEN")
+	}
+	if backend == "graphql" && !o.Sample {
+		login, err := checkToken(opts)
+		if err != nil {
+			return err
+		}
+		logger.Printf("GraphQL: authenticated as %s\n", login)
+	}
+
+	if o.SelfCheck && len(o.Handles) <= 1 {
+		var handle string
+		if len(o.Handles) == 1 {
+			handle = o.Handles[0]
+		}
+		handle, err := selfCheckUser(handle, opts)
+		if err != nil {
+			return err
+		}
+		return selfCheck(handle, strconv.Itoa(time.Now().Year()-1), opts)
+	}
+
+	sample := o.Sample
+	var userHandle string
+	var compareHandles []string
+	switch {
+	case sample:
+		userHandle = "sample"
+	case len(o.Handles) == 1:
+		userHandle = o.Handles[0]
+	case len(o.Handles) > 1:
+		// the users are compared side by side
+		handles, err := parseHandles(strings.Join(o.Handles, ","))
+		if err != nil {
+			return fmt.Errorf("compare users: %v", err)
+		}
+		compareHandles = handles
+		userHandle = strings.Join(handles, "-vs-")
+	default:
+		return errors.New("missing the GitHub-username")
+	}
+	// the years are validated before the handles are looked up
+	rawYears := o.Years
+	if o.SinceJoin && compareHandles != nil && !sample {
+		return errors.New("--since-join and --compare-users are mutually exclusive")
+	}
+	if !sample {
+		handles := compareHandles
+		if handles == nil {
+			handles = []string{userHandle}
+		}
+		for _, handle := range handles {
+			if err := checkHandle(handle, opts); err != nil {
+				return err
+			}
+		}
+	}
+	if o.HandleCaseNormalization && !sample {
+		var err error
+		if compareHandles != nil {
+			for i, handle := range compareHandles {
+				if compareHandles[i], err = canonicalHandle(handle, opts); err != nil {
+					return err
+				}
+			}
+			userHandle = strings.Join(compareHandles, "-vs-")
+		} else if userHandle, err = canonicalHandle(userHandle, opts); err != nil {
+			return err
+		}
+	}
+
+	delay := o.Delay
+	loop := o.Loop
+	if loop < -1 {
+		return fmt.Errorf("invalid loop %d, must be -1 to play once, 0 to loop forever or the number of times to loop", loop)
+	}
+	format := o.Format
+	if !contains(formats, format) {
+		return fmt.Errorf("invalid format %q, must be one of %s", format, strings.Join(formats, ","))
+	}
+	ext := "gif"
+	if format == "webp" {
+		ext = "webp"
+	}
+	if o.Extension != "" {
+		if ext, err = parseExtension(o.Extension); err != nil {
+			return err
+		}
+	}
+	outputDir, fileName, isFile := outputPath(o.OutDir, userHandle, ext)
+	if isFile {
+		if err := checkWritable(outputDir); err != nil {
+			return fmt.Errorf("out dir: %v", err)
+		}
+		ext = filepath.Ext(o.OutDir)[1:] // as typed, the extension matches case-insensitively
+	}
+	// the output path is used as is, only completed with the extension
+	if output := o.Output; output != "" {
+		if o.RunFolder {
+			return errors.New("--output and --run-folder are mutually exclusive")
+		}
+		if !strings.EqualFold(filepath.Ext(output), "."+ext) {
+			output += "." + ext
+		}
+		ext = filepath.Ext(output)[1:]
+		outputDir, fileName = filepath.Dir(output), strings.TrimSuffix(filepath.Base(output), "."+ext)
+		if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+			return fmt.Errorf("output: %v", err)
+		}
+	}
+	// the GIF streamed to standard output is not a file that can be amended or described afterwards
+	stdout := o.Stdout
+	if stdout {
+		if format != "gif" && format != "webp" {
+			return fmt.Errorf("--stdout only streams a GIF or a WebP, got --format %s", format)
+		}
+		for _, flag := range []struct {
+			name string
+			set  bool
+		}{
+			{"embed-source", o.EmbedSource},
+			{"validate-output", o.ValidateOutput},
+			{"sidecar", o.Sidecar},
+			{"inline-terminal", o.InlineTerminal},
+			{"live", o.Live != 0},
+		} {
+			if flag.set {
+				return fmt.Errorf("--stdout and --%s are mutually exclusive", flag.name)
+			}
+		}
+		if o.JSON == "-" {
+			return errors.New("--stdout and --json - are mutually exclusive")
+		}
+	}
+	paletteName := o.Palette
+	if !contains(palettes, paletteName) {
+		return fmt.Errorf("invalid palette %q, must be one of %s", paletteName, strings.Join(palettes, ","))
+	}
+	// a WebP keeps all the colors of the frames, whatever the palette, and is neither read nor amended as a GIF
+	encode := encodeGIF
+	if format == "webp" {
+		encode = encodeWebP
+		for _, flag := range []struct {
+			name string
+			set  bool
+		}{
+			{"compact-frames", o.CompactFrames},
+			{"embed-source", o.EmbedSource},
+			{"validate-output", o.ValidateOutput},
+		} {
+			if flag.set {
+				return fmt.Errorf("--%s only applies to GIFs, not to --format webp", flag.name)
+			}
+		}
+	}
+	if o.Reproducible {
+		if o.RunFolder {
+			return errors.New("--reproducible and --run-folder are mutually exclusive, the run folder is named after the time of the run")
+		}
+		// its range of years ends at the current one, which changes with the time of the run
+		if o.SinceJoin {
+			return errors.New("--reproducible and --since-join are mutually exclusive, the years run through the current one")
+		}
+	}
+	if o.RunFolder {
+		if outputDir, err = runFolder(outputDir, time.Now()); err != nil {
+			return err
+		}
+		logger.Printf("Run folder: %s\n", outputDir)
+	}
+	if o.FPS != 0 {
+		if delay, err = fpsDelay(o.FPS); err != nil {
+			return err
+		}
+	}
+
+	s := defaultStyle()
+	s.LabelPos = o.LabelPos
+	s.ShowStreak = o.ShowStreak
+	s.NoLabels = o.NoLabels
+	s.Smooth = o.Smooth
+	s.ScaleTicks = o.ScaleTicks
+	s.Grid = o.Grid
+	s.Legend = o.Legend
+	s.Rewind = o.Rewind
+	s.DitherFill = o.DitherFill
+	if o.LabelTemplate != "" {
+		if s.LabelTemplate, err = parseLabelTemplate(o.LabelTemplate); err != nil {
+			return fmt.Errorf("label-template: %v", err)
+		}
+	}
+	if o.BgGradient != "" {
+		if s.BgGradient, err = parseGradient(o.BgGradient); err != nil {
+			return fmt.Errorf("bg-gradient: %v", err)
+		}
+	}
+	if s.Transparent = o.Transparent; s.Transparent {
+		switch {
+		case s.BgGradient != nil:
+			return errors.New("--transparent and --bg-gradient are mutually exclusive")
+		case o.CompactFrames:
+			// a compacted frame is drawn over the previous one, which would show through its transparent pixels
+			return errors.New("--transparent and --compact-frames are mutually exclusive")
+		}
+	}
+	if o.OriginDot != "" {
+		if s.OriginColor, err = parseHexColor(o.OriginDot); err != nil {
+			return fmt.Errorf("origin-dot: %v", err)
+		}
+	}
+	padding := o.Padding
+	if padding < 0 {
+		return fmt.Errorf("invalid padding %d, must not be negative", padding)
+	}
+	var frameBg color.Color
+	if o.FrameBg != "" {
+		if frameBg, err = parseHexColor(o.FrameBg); err != nil {
+			return fmt.Errorf("frame-bg: %v", err)
+		}
+	}
+	l := layout{Width: float64(o.Size), MinDelta: o.MinDelta, SizeByTotal: o.SizeByTotal}
+	if l.SizeByTotal && backend != "graphql" && !sample {
+		return errors.New("--size-by-total requires --backend graphql, the profile only shows percentages")
+	}
+	if l.MinDelta < 0 || l.MinDelta > 1 {
+		return fmt.Errorf("invalid min delta %v, must be between 0 and 1", l.MinDelta)
+	}
+	if l.Width < minWidth {
+		return fmt.Errorf("invalid size %v, must be at least %d", l.Width, minWidth)
+	}
+	s = scaleStyle(s, l.Width/defaultWidth)
+	if s.LabelSize = o.LabelSize; s.LabelSize < 0 {
+		return fmt.Errorf("invalid label size %v, must not be negative", s.LabelSize)
+	}
+	if s.ValueSize = o.ValueSize; s.ValueSize < 0 {
+		return fmt.Errorf("invalid value size %v, must not be negative", s.ValueSize)
+	}
+	if o.HighlightMax {
+		s.HighlightColor = highlightColor
+	}
+	if o.ThemeFromProfile {
+		switch {
+		case sample || compareHandles != nil:
+			logger.Println("theme from profile: only available for a single GitHub user, using the default theme")
+		default:
+			if poly, axis, err := profileTheme(userHandle, opts); err != nil {
+				logger.Printf("theme from profile: %v, using the default theme\n", err)
+			} else {
+				s.PolyColor, s.AxisColor = poly, axis
+			}
+		}
+	}
+	// explicit colors take precedence over the theme
+	colorFlags := []struct {
+		name, hex string
+		color     *color.Color
+	}{
+		{"axis-color", o.AxisColor, &s.AxisColor},
+		{"poly-color", o.PolyColor, &s.PolyColor},
+		{"label-color", o.LabelColor, &s.LabelColor},
+		{"value-color", o.ValueColor, &s.ValueColor},
+	}
+	for _, f := range colorFlags {
+		if f.hex == "" {
+			continue
+		}
+		hex, err := parseHexColor(f.hex)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.name, err)
+		}
+		*f.color = hex
+	}
+	if !contains(labelPositions, s.LabelPos) {
+		return fmt.Errorf("invalid label position %q, must be one of %s", s.LabelPos, strings.Join(labelPositions, ","))
+	}
+	switch o.SpinLabels {
+	case "upright":
+		s.UprightLabels = true
+	case "rotate":
+		s.UprightLabels = false
+	default:
+		return fmt.Errorf("invalid spin labels %q, must be one of upright,rotate", o.SpinLabels)
+	}
+	s.AxisExtent = o.AxisExtent
+	if !contains(axisExtents, s.AxisExtent) {
+		return fmt.Errorf("invalid axis extent %q, must be one of %s", s.AxisExtent, strings.Join(axisExtents, ","))
+	}
+	s.Chart = o.Chart
+	if !contains(charts, s.Chart) {
+		return fmt.Errorf("invalid chart %q, must be one of %s", s.Chart, strings.Join(charts, ","))
+	}
+	if compareHandles != nil && s.Chart != "radar" {
+		return fmt.Errorf("compare users: only the radar chart can overlay several users, got %q", s.Chart)
+	}
+
+	// the font is parsed once, and a face of it created for every frame
+	font, err := loadFont(o.Font)
+	if err != nil {
+		return err
+	}
+
+	if diffYears := o.CompareDiffImage; diffYears != "" {
+		years := strings.Split(strings.Trim(diffYears, ", "), ",")
+		if len(years) != 2 {
+			return fmt.Errorf("compare diff image: expected two years, got %q", diffYears)
+		}
+		png, err := compareDiff(userHandle, years[0], years[1], outputDir, s, font, l, opts)
+		if err != nil {
+			return fmt.Errorf("compare diff image: %v", err)
+		}
+		logger.Printf("Created: %s\n", png)
+		return nil
+	}
+
+	var specificYears, activeYears []string
+	if sample {
+		for _, act := range sampleActivities {
+			specificYears = append(specificYears, act.Year)
+		}
+	} else {
+		if o.SinceJoin {
+			joined, err := joinYear(userHandle, opts)
+			if err != nil {
+				return err
+			}
+			if activeYears, err = parseYearFlag("all", userHandle, opts); err != nil {
+				return err
+			}
+			rawYears = fmt.Sprintf("%d-%d", joined, time.Now().Year())
+		}
+		if compareHandles != nil {
+			specificYears, err = unionYears(rawYears, compareHandles, opts)
+		} else {
+			specificYears, err = parseYearFlag(rawYears, userHandle, opts)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(specificYears) == 0 {
+		return errors.New("failed to parse any years")
+	}
+
+	boomerang := o.Boomerang
+	if boomerang {
+		n := o.BoomerangYears
+		if n < 1 {
+			return fmt.Errorf("invalid boomerang years %d, must be positive", n)
+		}
+		sort.Strings(specificYears)
+		if len(specificYears) > n {
+			specificYears = specificYears[len(specificYears)-n:]
+		}
+	}
+
+	finalDelay := delay
+	switch {
+	case o.FinalDelay < 0:
+		return fmt.Errorf("invalid final delay %d, must not be negative", o.FinalDelay)
+	case o.FinalDelay > 0:
+		finalDelay = o.FinalDelay
+	}
+	titleDelay := 2 * delay
+	switch {
+	case o.TitleDelay < 0:
+		return fmt.Errorf("invalid title delay %d, must not be negative", o.TitleDelay)
+	case o.TitleDelay > 0:
+		titleDelay = o.TitleDelay
+	}
+
+	chanSize := len(specificYears)
+	concurrency := o.Concurrency
+	if concurrency < 0 {
+		return fmt.Errorf("invalid concurrency %d, must not be negative", concurrency)
+	}
+
+	fetcher, err := newActivityFetcher(backend, opts)
+	if err != nil {
+		return err
+	}
+	scrape := scraper(fetcher.Fetch)
+	kind := backend
+	switch {
+	case s.Chart == "calendar":
+		scrape = func(handle, year string) (activity, error) {
+			return calendarActivity(handle, year, opts)
+		}
+		kind = "calendar"
+	case s.ShowStreak:
+		scrape = withStreak(scrape, opts)
+		kind += "-streak"
+	}
+	scrape = withMetrics(scrape, stats)
+	// the cached activities are not scrapes, and not counted as such in the metrics
+	if !o.NoCache && !sample {
+		ttl := o.CacheTTL
+		if ttl < 0 {
+			return fmt.Errorf("invalid cache ttl %v, must not be negative", ttl)
+		}
+		dir, err := defaultCacheDir()
+		if err != nil {
+			return fmt.Errorf("cache: %v", err)
+		}
+		if opts.BaseURL != "" {
+			// the same handle is a different user on every instance
+			dir = filepath.Join(dir, strings.NewReplacer("://", "_", ":", "_").Replace(opts.BaseURL))
+		}
+		scrape = withCache(scrape, activityCache{Dir: dir, Kind: kind, TTL: ttl})
+	}
+	// the baseline is not a frame, it is neither shown in the progress nor listed among the missing years
+	baselineScrape := scrape
+
+	// every user is scraped on every year, and every year is a frame unless they are merged
+	var prog *progress
+	if !quiet {
+		handles, frames := 1, chanSize
+		if compareHandles != nil {
+			handles = len(compareHandles)
+		}
+		if sample {
+			handles = 0
+		}
+		switch {
+		case o.Report || o.DryRun || format == "json":
+			frames = 0
+		case o.MergeYears:
+			frames = 1
+		}
+		prog = newProgress(os.Stderr, handles*chanSize, frames)
+	}
+	scrape = withProgress(scrape, prog)
+	failed := &failures{ShowHandle: compareHandles != nil}
+	scrape = withFailures(scrape, failed)
+	strict := o.Strict
+	if !strict {
+		defer func() {
+			if missing := failed.list(); len(missing) > 0 {
+				logger.Printf("Warning: failed to scrape %s, missing from the output\n", strings.Join(missing, ", "))
+			}
+		}()
+	}
+	if activeYears != nil {
+		scrape = withPlaceholders(scrape, activeYears)
+	}
+
+	jsonPath := o.JSON
+	dryRun := o.DryRun
+	switch {
+	case o.Report && format == "json":
+		return errors.New("--report and --format json are mutually exclusive")
+	case dryRun && o.Report:
+		return errors.New("--dry-run and --report are mutually exclusive")
+	case dryRun && format == "json":
+		return errors.New("--dry-run and --format json are mutually exclusive")
+	}
+	if o.Report || dryRun || format == "json" {
+		handles := compareHandles
+		if handles == nil {
+			handles = []string{userHandle}
+		}
+		acts := []activity{}
+		for _, handle := range handles {
+			var actc <-chan activity
+			if sample {
+				actc = genSampleActivities(genYears(specificYears, chanSize), chanSize)
+			} else {
+				actc = genActivities(handle, genYears(specificYears, chanSize), chanSize, concurrency, scrape)
+			}
+			if o.MergeYears {
+				actc = mergeActivities(actc)
+			}
+			for act := range actc {
+				acts = append(acts, act)
+			}
+		}
+		if missing := failed.list(); strict && len(missing) > 0 {
+			return fmt.Errorf("strict: failed to scrape %s", strings.Join(missing, ", "))
+		}
+		if len(acts) == 0 {
+			return fmt.Errorf("Failed to scrape a single activity for %s", userHandle)
+		}
+		if o.Report {
+			return writeReport(os.Stdout, acts)
+		}
+		if dryRun {
+			return writeTable(os.Stdout, acts)
+		}
+		if jsonPath == "" {
+			if err := ensureDir(outputDir); err != nil {
+				return err
+			}
+			jsonPath = filepath.Join(outputDir, fileName+".json")
+		}
+		if err := writeActivities(jsonPath, acts); err != nil {
+			return fmt.Errorf("JSON: %v", err)
+		}
+		if jsonPath != "-" {
+			logger.Printf("Created: %s\n", jsonPath)
+		}
+		return nil
+	}
+
+	var baseline *activity
+	if year := o.BaselineYear; year != "" {
+		switch {
+		case compareHandles != nil:
+			return errors.New("--baseline-year and --compare-users are mutually exclusive")
+		case s.Chart != "radar":
+			return fmt.Errorf("baseline year: only the radar chart can draw a baseline, got %q", s.Chart)
+		}
+		act, err := baselineActivity(userHandle, year, sample, baselineScrape)
+		if err != nil {
+			return err
+		}
+		baseline = &act
+	}
+
+	// processing pipeline, with a source of years for every user
+	var graphc <-chan graph
+	if compareHandles != nil {
+		actcs := make([]<-chan activity, len(compareHandles))
+		for i, handle := range compareHandles {
+			actcs[i] = genActivities(handle, genYears(specificYears, chanSize), chanSize, concurrency, scrape)
+			if o.MergeYears {
+				actcs[i] = mergeActivities(actcs[i])
+			}
+		}
+		graphc = overlayGraphs(actcs, strings.Join(compareHandles, " vs "), chanSize, l)
+	} else {
+		yearc := genYears(specificYears, chanSize)
+		var actc <-chan activity
+		if sample {
+			actc = genSampleActivities(yearc, chanSize)
+		} else {
+			actc = genActivities(userHandle, yearc, chanSize, concurrency, scrape)
+		}
+		if o.MergeYears {
+			actc = mergeActivities(actc)
+		}
+		graphc = genGraph(actc, baseline, chanSize, o.DumpCoords, o.Deltas, l)
+	}
+	spin := o.Spin
+	if spin < 0 {
+		return fmt.Errorf("invalid spin %d, must not be negative", spin)
+	}
+	var acts []activity
+	if o.Sidecar || spin > 0 || jsonPath != "" || o.EmitFramesMetadata {
+		graphc = recordActivities(graphc, &acts, chanSize)
+	}
+	renderTimeout := o.RenderTimeout
+	if renderTimeout < 0 {
+		return fmt.Errorf("invalid render timeout %v, must not be negative", renderTimeout)
+	}
+	// the rendering and encoding stages share the clock, which starts once the scraping is over
+	clock := newRenderClock(ctx, renderTimeout)
+	defer clock.stop()
+	ctx = clock.Ctx
+	imgc := trackRenders(genImg(graphc, chanSize, s, font, stats, clock), chanSize, prog)
+
+	// pipeline sink
+	live := o.Live
+	gifOpts := gifOptions{
+		FirstDelay:  delay,
+		Delay:       delay,
+		FinalDelay:  finalDelay,
+		Loop:        loop,
+		Compact:     o.CompactFrames,
+		Transparent: s.Transparent,
+	}
+	reverse := o.Reverse || s.Rewind
+	yearImgs, err := bundleImgs(imgc, live, reverse, func(frames []image.Image) {
+		previewOpts := gifOpts
+		previewOpts.Palette = gifPalette(paletteName, frames, s.Transparent)
+		preview, err := createGIF(ctx, frames, outputDir, "latest", ext, previewOpts, encode)
+		if err != nil {
+			logger.Printf("live preview: %v\n", err)
+			return
+		}
+		logger.Printf("Preview: %s (%d frames)\n", preview, len(frames))
+	})
+	if err != nil {
+		return err
+	}
+	if missing := failed.list(); strict && len(missing) > 0 {
+		return fmt.Errorf("strict: failed to scrape %s", strings.Join(missing, ", "))
+	}
+	if len(yearImgs) == 0 {
+		return fmt.Errorf("Failed to create a single image for %s", userHandle)
+	}
+	imgs := sortImgs(yearImgs, reverse)
+	// the description of every frame, following the frames as they are added and reordered
+	metas := make([]frameMeta, len(yearImgs))
+	for i, yearImg := range yearImgs {
+		metas[i].Year = yearImg.Year
+	}
+
+	// the activities are all recorded once the images are bundled
+	if jsonPath != "" {
+		if err := writeActivities(jsonPath, acts); err != nil {
+			return fmt.Errorf("JSON: %v", err)
+		}
+		if jsonPath != "-" {
+			logger.Printf("Created: %s\n", jsonPath)
+		}
+	}
+
+	// the intro turns the chart of the first year shown, which leads into its own frame
+	intro := 0
+	if spin > 0 {
+		if compareHandles != nil || s.Chart != "radar" {
+			logger.Println("spin: only available for the radar chart of a single user")
+		} else {
+			sort.Slice(acts, func(i, j int) bool {
+				if reverse {
+					return acts[i].Year > acts[j].Year
+				}
+				return acts[i].Year < acts[j].Year
+			})
+			if l.SizeByTotal {
+				l.MaxTotal = maxTotal(withBaselineActivity(acts, baseline))
+			}
+			spinning, err := spinImgs(ctx, acts[0], baseline, spin, l, s, font)
+			if err != nil {
+				return fmt.Errorf("spin: %v", err)
+			}
+			intro = len(spinning)
+			imgs = append(spinning, imgs...)
+			for i := 0; i < intro; i++ {
+				metas = append([]frameMeta{{Year: acts[0].Year, Intro: true}}, metas...)
+			}
+		}
+	}
+
+	// the title frame opens the GIF, before the intro
+	title := 0
+	if o.Title {
+		if format == "png" {
+			logger.Println("title: ignored with --format png")
+		} else {
+			handle := userHandle
+			if compareHandles != nil {
+				handle = strings.Join(compareHandles, " vs ")
+			}
+			title = 1
+			imgs = append([]image.Image{titleImg(handle, imgs[0].Bounds(), s, font)}, imgs...)
+			metas = append([]frameMeta{{Title: true}}, metas...)
+		}
+	}
+
+	if o.Autocrop {
+		imgs = autocrop(imgs, autocropMargin)
+	}
+	if padding > 0 {
+		imgs = pad(imgs, padding, frameBg)
+	} else if frameBg != nil {
+		logger.Println("frame bg: ignored without --padding")
+	}
+
+	if format == "png" {
+		if err := ensureDir(outputDir); err != nil {
+			return err
+		}
+		for i, yearImg := range yearImgs {
+			// colons are not valid in Windows file names
+			year := strings.Replace(yearImg.Year, ":", "_", -1)
+			file := filepath.Join(outputDir, fmt.Sprintf("%s-%s.png", fileName, year))
+			if err := stopped(ctx); err != nil {
+				return fmt.Errorf("PNG: %v", err)
+			}
+			if err := writePNG(file, imgs[intro+i]); err != nil {
+				return fmt.Errorf("PNG: %v", err)
+			}
+			logger.Printf("Created: %s\n", file)
+		}
+		return nil
+	}
+
+	if boomerang || o.Bounce {
+		// the title frame and the intro are only played once, not bounced back to
+		imgs, metas = bounceFrom(imgs, metas, title+intro)
+	}
+
+	repeat := o.RepeatLast
+	if repeat < 0 {
+		return fmt.Errorf("invalid repeat last %d, must not be negative", repeat)
+	}
+	for i := 0; i < repeat; i++ {
+		imgs = append(imgs, imgs[len(imgs)-1])
+		metas = append(metas, metas[len(metas)-1])
+	}
+
+	if title > 0 {
+		gifOpts.FirstDelay = titleDelay
+	}
+	if format == "gif" {
+		gifOpts.Palette = gifPalette(paletteName, imgs, s.Transparent)
+	}
+	encodeStart := time.Now()
+	var gif string
+	if stdout {
+		err = encode(ctx, os.Stdout, imgs, gifOpts)
+	} else {
+		gif, err = createGIF(ctx, imgs, outputDir, fileName, ext, gifOpts, encode)
+	}
+	if err != nil {
+		return fmt.Errorf("GIF: %v", err)
+	}
+	stats.encode(encodeStart)
+	if stdout {
+		gif = "standard output"
+	}
+
+	if o.EmbedSource {
+		if sample {
+			logger.Println("embed source: the sample activity has no source")
+		} else {
+			handles := compareHandles
+			if handles == nil {
+				handles = []string{userHandle}
+			}
+			urls := sourceURLs(opts.webURL(""), handles, specificYears, s.Chart == "calendar", s.ShowStreak)
+			for i := range urls {
+				urls[i] = redactURL(urls[i])
+			}
+			if err := embedComment(gif, "Source:\n"+strings.Join(urls, "\n")); err != nil {
+				return fmt.Errorf("embed source: %v", err)
+			}
+		}
+	}
+
+	if o.ValidateOutput {
+		if err := validateGIF(gif, imgs); err != nil {
+			if rmErr := os.Remove(gif); rmErr != nil {
+				logger.Printf("validate output: %v\n", rmErr)
+			}
+			return fmt.Errorf("validate output: %v, removed %s", err, gif)
+		}
+	}
+
+	logger.Printf("Created: %s\n", gif)
+
+	if dir := o.FramesDir; dir != "" {
+		if err := exportFrames(imgs, dir, userHandle, o.DiffFrames); err != nil {
+			return fmt.Errorf("frames dir: %v", err)
+		}
+		logger.Printf("Frames: %s (%d frames)\n", dir, len(imgs))
+
+		if o.EmitFramesMetadata {
+			byYear := map[string][]sidecarActivity{}
+			for _, a := range sidecarActivities(acts) {
+				byYear[a.Year] = append(byYear[a.Year], a)
+			}
+			for i := range metas {
+				metas[i].Index = i
+				metas[i].File = filepath.Join(dir, frameName(userHandle, i)+".png")
+				metas[i].Delay = delay
+				metas[i].Activities = byYear[metas[i].Year]
+			}
+			metas[0].Delay = gifOpts.FirstDelay
+			metas[len(metas)-1].Delay = finalDelay
+			path, err := writeFramesMetadata(dir, metas)
+			if err != nil {
+				return fmt.Errorf("frames metadata: %v", err)
+			}
+			logger.Printf("Created: %s\n", path)
+		}
+	} else {
+		if o.DiffFrames {
+			logger.Println("diff frames: ignored without --frames-dir")
+		}
+		if o.EmitFramesMetadata {
+			logger.Println("emit frames metadata: ignored without --frames-dir")
+		}
+	}
+
+	if o.InlineTerminal {
+		if err := showInline(os.Stdout, gif, imgs[len(imgs)-1]); err != nil {
+			logger.Printf("inline terminal: %v\n", err)
+		}
+	}
+
+	if o.Sidecar {
+		meta := sidecar{
+			Version:    toolVersion(),
+			Options:    o.SidecarOptions,
+			Activities: sidecarActivities(acts),
+			Frames:     len(imgs),
+			Delay:      delay,
+			Width:      imgs[0].Bounds().Dx(),
+			Height:     imgs[0].Bounds().Dy(),
+		}
+		if !o.Reproducible {
+			meta.Created = time.Now().Format(time.RFC3339)
+		}
+		path, err := writeSidecar(gif, meta)
+		if err != nil {
+			return fmt.Errorf("sidecar: %v", err)
+		}
+		logger.Printf("Created: %s\n", path)
+	}
+
+	return nil
+}
+
+// defaultConcurrency is the number of years scraped at once, enough to be quick without tripping GitHub's rate limits
+const defaultConcurrency = 4
+
+// autocropMargin is the whitespace in pixels kept around the graphs by --autocrop
+const autocropMargin = 10
+
+// selfCheckHandle is a profile of GitHub active in all metrics, used as reference to detect markup changes
+// it does not exist on GitHub Enterprise instances, whose self-check needs a handle of their own
+const selfCheckHandle = "sindresorhus"
+
+// selfCheckUser returns the handle checked by --self-check, selfCheckHandle unless a handle is given
+func selfCheckUser(handle string, opts fetchOptions) (string, error) {
+	switch {
+	case handle != "":
+		return handle, nil
+	case opts.BaseURL != "":
+		return "", fmt.Errorf("self-check: pass the GitHub-username of a profile of %s, %s is a profile of GitHub", opts.BaseURL, selfCheckHandle)
+	}
+	return selfCheckHandle, nil
+}
+
+// selfCheck scrapes the activity of a user on a given year and reports
+// whether every metric was parsed to a plausible non-zero value
+func selfCheck(handle, year string, opts fetchOptions) error {
+	act, err := parseActivity(handle, year, opts)
+	if err != nil {
+		fmt.Printf("self-check %s %s: FAIL\n", handle, year)
+		return fmt.Errorf("self-check: %v", err)
+	}
+
+	fmt.Printf("self-check %s %s: commits=%d%% issues=%d%% prs=%d%% code reviews=%d%%\n",
+		handle, year, act.Commits, act.Issues, act.Prs, act.CodeReviews)
+
+	zero := []string{}
+	for metric, value := range map[string]int{
+		"code reviews": act.CodeReviews,
+		"commits":      act.Commits,
+		"issues":       act.Issues,
+		"prs":          act.Prs,
+	} {
+		if value == 0 {
+			zero = append(zero, metric)
+		}
+	}
+	sort.Strings(zero)
+
+	switch {
+	case len(zero) == 4:
+		fmt.Printf("self-check %s %s: FAIL\n", handle, year)
+		return errors.New("self-check: every metric scraped as 0%, scraping appears broken")
+	case len(zero) > 0:
+		fmt.Printf("self-check %s %s: FAIL\n", handle, year)
+		return fmt.Errorf("self-check: %s scraped as 0%%, scraping may be broken", strings.Join(zero, ", "))
+	}
+
+	fmt.Printf("self-check %s %s: PASS\n", handle, year)
+	return nil
+}
+
+// genYears fans out every year to scrape the activity into a channel
+func genYears(years []string, size int) <-chan string {
+	var out = make(chan string, size)
+	go func() {
+		defer close(out)
+		for _, y := range years {
+			out <- y
+		}
+	}()
+	return out
+}
+
+// genActivities creates and passes activities into a channel for every year in the input channel
+// a pool of concurrency workers fetches the years from fetcher, 0 for a worker per year. With a single worker the years are
+// fetched one after the other and the activities passed in the order of the input channel
+func genActivities(handle string, in <-chan string, size, concurrency int, fetcher activityFetcher) <-chan activity {
+	var out = make(chan activity, size)
+	workers := concurrency
+	if workers == 0 || workers > size {
+		workers = size
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for year := range in {
+				act, err := fetcher.Fetch(handle, year)
+				if err != nil {
+					logger.Printf("scrape activity for %s: %v\n", year, err)
+					continue
+				}
+				logger.Printf("Activity: %+v\n", act)
+				out <- act
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// sampleActivities is the built-in activity used by --sample
+var sampleActivities = []activity{
+	{Handle: "sample", Year: "2016", Commits: 92, Issues: 5, Prs: 3, CodeReviews: 0, Total: 212},
+	{Handle: "sample", Year: "2017", Commits: 78, Issues: 12, Prs: 8, CodeReviews: 2, Total: 348},
+	{Handle: "sample", Year: "2018", Commits: 61, Issues: 14, Prs: 17, CodeReviews: 8, Total: 517},
+	{Handle: "sample", Year: "2019", Commits: 48, Issues: 11, Prs: 23, CodeReviews: 18, Total: 803},
+	{Handle: "sample", Year: "2020", Commits: 39, Issues: 9, Prs: 25, CodeReviews: 27, Total: 1094},
+}
+
+// genSampleActivities passes the sample activity into a channel for every year in the input channel
+func genSampleActivities(in <-chan string, size int) <-chan activity {
+	var out = make(chan activity, size)
+	go func() {
+		defer close(out)
+		for year := range in {
+			for _, act := range sampleActivities {
+				if act.Year == year {
+					out <- act
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// mergeActivities passes a single activity into a channel, averaging all the activities of the input channel
+// years that failed to be scraped are not part of the input, so they do not weigh on the average
+func mergeActivities(in <-chan activity) <-chan activity {
+	var out = make(chan activity, 1)
+	go func() {
+		defer close(out)
+		acts := []activity{}
+		for act := range in {
+			acts = append(acts, act)
+		}
+		if len(acts) == 0 {
+			return
+		}
+		out <- averageActivity(acts)
+	}()
+	return out
+}
+
+// averageActivity returns the average percentages and total of the activities
+// labeled with the range of years they span, the streak is the longest of those known
+func averageActivity(acts []activity) activity {
+	sort.Slice(acts, func(i, j int) bool {
+		return acts[i].Year < acts[j].Year
+	})
+
+	var commits, issues, prs, codeReviews, total int
+	merged := activity{Handle: acts[0].Handle, Year: acts[0].Year, Streak: unknownStreak}
+	for _, act := range acts {
+		commits += act.Commits
+		issues += act.Issues
+		prs += act.Prs
+		codeReviews += act.CodeReviews
+		total += act.Total
+		if act.Streak > merged.Streak {
+			merged.Streak = act.Streak
+		}
+	}
+	if last := acts[len(acts)-1].Year; last != merged.Year {
+		merged.Year = fmt.Sprintf("%s–%s", merged.Year, last)
+	}
+
+	n := float64(len(acts))
+	merged.Commits = int(math.Round(float64(commits) / n))
+	merged.Issues = int(math.Round(float64(issues) / n))
+	merged.Prs = int(math.Round(float64(prs) / n))
+	merged.CodeReviews = int(math.Round(float64(codeReviews) / n))
+	merged.Total = int(math.Round(float64(total) / n))
+
+	return merged
+}
+
+// genGraph creates and passes graphs into a channel for every activity in the input channel
+// if dump is set, the coordinates of every graph are logged
+// with deltas, the activities are collected and sorted by year first so every graph holds the previous year
+// as they are with l.SizeByTotal, to scale every graph by the largest total of all
+// every graph holds the polygon of the baseline base drawn underneath, unless base is nil
+func genGraph(in <-chan activity, base *activity, size int, dump, deltas bool, l layout) <-chan graph {
+	var out = make(chan graph, size)
+	go func() {
+		defer close(out)
+		emit := func(act activity, prev *activity) {
+			g := graph{Data: act, Coords: coordinates(act, l), Prev: prev, Baseline: baselineCoords(base, l)}
+			if dump {
+				logger.Printf("Coords %s: %+v\n", act.Year, g.Coords)
+			}
+			out <- g
+		}
+
+		if !deltas && !l.SizeByTotal {
+			for act := range in {
+				emit(act, nil)
+			}
+			return
+		}
+
+		acts := []activity{}
+		for act := range in {
+			acts = append(acts, act)
+		}
+		sort.Slice(acts, func(i, j int) bool {
+			return acts[i].Year < acts[j].Year
+		})
+		if l.SizeByTotal {
+			l.MaxTotal = maxTotal(withBaselineActivity(acts, base))
+		}
+		for i, act := range acts {
+			var prev *activity
+			if deltas && i > 0 {
+				prev = &acts[i-1]
+			}
+			emit(act, prev)
+		}
+	}()
+	return out
+}
+
+// baselineActivity returns the activity of the --baseline-year of a user, scraped once with scrape
+// scrape is not the one of the frames, whose progress and failures would count the baseline as a year
+func baselineActivity(handle, year string, sample bool, scrape scraper) (activity, error) {
+	if sample {
+		for _, act := range sampleActivities {
+			if act.Year == year {
+				return act, nil
+			}
+		}
+		return activity{}, fmt.Errorf("baseline year: the sample has no activity in %s", year)
+	}
+	act, err := scrape(handle, year)
+	if err != nil {
+		return activity{}, fmt.Errorf("baseline year: %s is unavailable: %v", year, err)
+	}
+	return act, nil
+}
+
+// baselineCoords returns the polygon of the baseline base drawn with l, nil without a baseline
+// with l.SizeByTotal, l.MaxTotal must be set for the baseline to be scaled as the graphs are
+func baselineCoords(base *activity, l layout) *coords {
+	if base == nil {
+		return nil
+	}
+	c := coordinates(*base, l)
+	return &c
+}
+
+// withBaselineActivity returns the activities with the baseline base, if any, to scale them all alike
+func withBaselineActivity(acts []activity, base *activity) []activity {
+	if base == nil {
+		return acts
+	}
+	return append([]activity{*base}, acts...)
+}
+
+// genImg creates and passes images into a channel for every graph description in the input channel
+// the images are drawn with the colors and layout of base in font, and their rendering time recorded in m
+// once the input channel is closed the scraping is over and the render timeout of clock starts,
+// a frame not started before the timeout expires is passed as an image with an error
+func genImg(in <-chan graph, size int, base style, font *truetype.Font, m *metrics, clock *renderClock) <-chan activityImage {
+	var out = make(chan activityImage, size)
+	var wg sync.WaitGroup
+	wg.Add(size)
+	activeGoRoutines := 0
+	go func() {
+		for g := range in {
+			activeGoRoutines++
+			go func(g graph) {
+				defer wg.Done()
+				if err := stopped(clock.Ctx); err != nil {
+					out <- activityImage{Year: g.Data.Year, Err: fmt.Errorf("render %s: %v", g.Data.Year, err)}
+					return
+				}
+				defer m.render(time.Now())
+				render := img
+				if base.Chart == "calendar" {
+					render = calendarImg
+				}
+				// font faces are not safe for concurrent use, create them per goroutine
+				out <- activityImage{render(g, withFonts(base, font)), g.Data.Year, nil}
+			}(g)
+		}
+		clock.start()
+		// when input channel is closed, reduce the waitgroup counter
+		// by the number of goroutines that were expected but not created
+		for i := 0; i < size-activeGoRoutines; i++ {
+			wg.Done()
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// defaultStyle returns the style of GitHub's activity overview graph, without fonts
+func defaultStyle() style {
+	return style{
+		MarkerRadius:  6,
+		Scale:         1,
+		LabelColor:    color.RGBA{88, 96, 105, 0xff},
+		ValueColor:    color.RGBA{149, 157, 165, 0xff},
+		AxisColor:     color.RGBA{108, 178, 103, 0xff},
+		PolyColor:     color.RGBA{123, 201, 111, 0xff},
+		LabelPos:      "bottom",
+		Chart:         "radar",
+		LabelTemplate: defaultLabelTemplate,
+		AxisExtent:    "full",
+		UprightLabels: true,
+	}
+}
+
+// loadFont parses the TTF file at path, or the embedded Go Regular font without a path
+// a file that cannot be read or parsed falls back to Go Regular with a warning
+func loadFont(path string) (*truetype.Font, error) {
+	if path != "" {
+		ttf, err := ioutil.ReadFile(path)
+		if err == nil {
+			var f *truetype.Font
+			if f, err = truetype.Parse(ttf); err == nil {
+				return f, nil
+			}
+		}
+		logger.Printf("font: %v, falling back to Go Regular\n", err)
+	}
+	return truetype.Parse(goregular.TTF)
+}
+
+// withFonts returns a copy of s with label and value faces of font f
+func withFonts(s style, f *truetype.Font) style {
+	labelSize, valueSize := 24*s.Scale, 22*s.Scale
+	if s.LabelSize > 0 {
+		labelSize = s.LabelSize
+	}
+	if s.ValueSize > 0 {
+		valueSize = s.ValueSize
+	}
+	s.LabelFont = truetype.NewFace(f, &truetype.Options{Size: labelSize})
+	s.ValueFont = truetype.NewFace(f, &truetype.Options{Size: valueSize})
+	s.TickFont = truetype.NewFace(f, &truetype.Options{Size: 14 * s.Scale})
+	return s
+}
+
+// scaleStyle returns s with its fonts and markers scaled by scale, for a canvas scaled as much
+func scaleStyle(s style, scale float64) style {
+	s.Scale *= scale
+	s.MarkerRadius *= scale
+	return s
+}
+
+// bundleImgs collects all the activity images in the input channel, sorted by year
+// if live is positive, preview is called with the frames received so far every live frames
+func bundleImgs(in <-chan activityImage, live int, reverse bool, preview func([]image.Image)) ([]activityImage, error) {
+	// receive all activity images
+	unsortedImgs := []activityImage{}
+	for i := range in {
+		if i.Err != nil {
+			// the frames left are not rendered past the render timeout, wait for them so none outlives the run
+			for range in {
+			}
+			return nil, i.Err
+		}
+		unsortedImgs = append(unsortedImgs, i)
+		if live > 0 && len(unsortedImgs)%live == 0 {
+			preview(sortImgs(unsortedImgs, reverse))
+		}
+	}
+
+	sortImgs(unsortedImgs, reverse)
+	return unsortedImgs, nil
+}
+
+// sortImgs sorts the activity images by year, in place, and returns their images
+// the newest year comes first if reverse is set
+func sortImgs(unsortedImgs []activityImage, reverse bool) []image.Image {
+	sort.Slice(unsortedImgs, func(i, j int) bool {
+		if reverse {
+			return unsortedImgs[i].Year > unsortedImgs[j].Year
+		}
+		return unsortedImgs[i].Year < unsortedImgs[j].Year
+	})
+
+	// output sorted images
+	numFrames := len(unsortedImgs)
+	sortedImgs := make([]image.Image, numFrames)
+	for i := 0; i < numFrames; i++ {
+		sortedImgs[i] = unsortedImgs[i].Img
+	}
+
+	return sortedImgs
+}
+
+// autocrop crops every frame to the union of the bounding boxes of their content, expanded by margin
+// using the same rectangle for every frame keeps the animation from jittering
+func autocrop(frames []image.Image, margin int) []image.Image {
+	crop := image.Rectangle{}
+	for _, f := range frames {
+		crop = crop.Union(contentBounds(f))
+	}
+	if crop.Empty() {
+		return frames
+	}
+	crop = crop.Inset(-margin).Intersect(frames[0].Bounds())
+
+	cropped := make([]image.Image, len(frames))
+	for i, f := range frames {
+		// copy the frame so that the cropped image starts at the origin, as GIF frames are offset by Min
+		dst := image.NewRGBA(image.Rect(0, 0, crop.Dx(), crop.Dy()))
+		draw.Draw(dst, dst.Bounds(), f, crop.Min, draw.Src)
+		cropped[i] = dst
+	}
+	return cropped
+}
+
+// pad surrounds every frame with a border of margin pixels in color bg
+// a nil bg extends the edge pixels of every frame instead, so the border blends with the background, gradients included
+func pad(frames []image.Image, margin int, bg color.Color) []image.Image {
+	padded := make([]image.Image, len(frames))
+	for i, f := range frames {
+		b := f.Bounds()
+		dst := image.NewRGBA(image.Rect(0, 0, b.Dx()+2*margin, b.Dy()+2*margin))
+		if bg != nil {
+			draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+			draw.Draw(dst, dst.Bounds().Inset(margin), f, b.Min, draw.Src)
+			padded[i] = dst
+			continue
+		}
+		clamp := func(v, max int) int {
+			if v < 0 {
+				return 0
+			}
+			if v >= max {
+				return max - 1
+			}
+			return v
+		}
+		for y := 0; y < dst.Rect.Dy(); y++ {
+			for x := 0; x < dst.Rect.Dx(); x++ {
+				dst.Set(x, y, f.At(b.Min.X+clamp(x-margin, b.Dx()), b.Min.Y+clamp(y-margin, b.Dy())))
+			}
+		}
+		padded[i] = dst
+	}
+	return padded
+}
+
+// contentBounds returns the bounding box of the pixels of f that differ from the background
+// the background of every row is its leftmost pixel, to account for vertical gradients
+func contentBounds(f image.Image) image.Rectangle {
+	b := f.Bounds()
+	content := image.Rectangle{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		br, bg, bb, ba := f.At(b.Min.X, y).RGBA()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, g, b, a := f.At(x, y).RGBA(); r != br || g != bg || b != bb || a != ba {
+				content = content.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return content
+}
+
+// fpsDelay converts a frame rate into a GIF transition delay
+// GIF delays are whole centiseconds, so the delay is rounded to the closest one
+func fpsDelay(fps float64) (int, error) {
+	if fps <= 0 || math.IsNaN(fps) || math.IsInf(fps, 0) {
+		return 0, fmt.Errorf("invalid fps %g, must be positive", fps)
+	}
+	delay := int(math.Round(100 / fps))
+	if delay < 1 {
+		delay = 1
+	}
+	if actual := 100 / float64(delay); actual != fps {
+		logger.Printf("GIF delays are in centiseconds, %g fps is approximated as %.4g fps (delay %d)\n", fps, actual, delay)
+	}
+	return delay, nil
+}
+
+// bounce appends the frames in reverse order, excluding both endpoints,
+// so that looping the animation plays it back and forth
+func bounce(frames []image.Image) []image.Image {
+	bounced := []image.Image{}
+	for _, i := range bounceOrder(len(frames)) {
+		bounced = append(bounced, frames[i])
+	}
+	return bounced
+}
+
+// bounceFrom bounces the frames and their metadata past the first head ones, which are only played once
+func bounceFrom(imgs []image.Image, metas []frameMeta, head int) ([]image.Image, []frameMeta) {
+	bounced := append([]frameMeta{}, metas[:head]...)
+	for _, j := range bounceOrder(len(metas) - head) {
+		bounced = append(bounced, metas[head+j])
+	}
+	return append(imgs[:head:head], bounce(imgs[head:])...), bounced
+}
+
+// bounceOrder returns the indices of n frames in the order played by bounce
+func bounceOrder(n int) []int {
+	order := []int{}
+	for i := 0; i < n; i++ {
+		order = append(order, i)
+	}
+	for i := n - 2; i > 0; i-- {
+		order = append(order, i)
+	}
+	return order
+}
+
+// gifOptions are the settings of the GIF encoding
+type gifOptions struct {
+	// the first frame is shown for FirstDelay, the final frame for FinalDelay and the others for Delay
+	FirstDelay, Delay, FinalDelay int
+	// Loop is the GIF loop count: 0 loops forever, -1 plays once and N restarts the animation N times
+	Loop int
+	// with Compact, every frame after the first only holds the region that changed since the previous one
+	Compact bool
+	// with Transparent, every frame is cleared before the next, Palette must then hold a transparent color
+	Transparent bool
+	// Palette holds the colors the frames are reduced to, see gifPalette
+	Palette color.Palette
+}
+
+// createGIF bundles the frames with encode, encodeGIF or encodeWebP, to create <userhandle>.<ext> in the output directory
+// the file is removed if the encoding fails, such as when ctx is done
+func createGIF(ctx context.Context, frames []image.Image, outputDir, userHandle, ext string, opts gifOptions, encode encoder) (string, error) {
+	if err := ensureDir(outputDir); err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("%s.%s", userHandle, ext)
+	f, err := os.Create(filepath.Join(outputDir, fileName))
+	if err != nil {
+		return "", err
+	}
+	if err := encode(ctx, f, frames, opts); err != nil {
+		f.Close()
+		if rmErr := os.Remove(f.Name()); rmErr != nil {
+			logger.Printf("remove partial output: %v\n", rmErr)
+		}
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// encoder bundles the frames into an animation written to w, stopping early once ctx is done
+type encoder func(ctx context.Context, w io.Writer, frames []image.Image, opts gifOptions) error
+
+// encodeGIF bundles the frames into a GIF written to w, it is an encoder
+func encodeGIF(ctx context.Context, w io.Writer, frames []image.Image, opts gifOptions) error {
+	switch {
+	case len(frames) == 0:
+		return errors.New("GIF: no images to bundle")
+	case opts.Delay == 0 && len(frames) > 1:
+		// a single frame is a static GIF, which has no transition
+		return errors.New("GIF: no transition delay given, a delay of 0 is only valid for a single frame")
+	}
+
+	// create appropriate image type for GIF encoding
+	numFrames := len(frames)
+	palettedImgs := []*image.Paletted{}
+	for i, f := range frames {
+		if err := stopped(ctx); err != nil {
+			return err
+		}
+		bounds := f.Bounds()
+		if opts.Compact && i > 0 {
+			bounds = changedBounds(frames[i-1], f)
+		}
+		paletted := image.NewPaletted(bounds, opts.Palette)
+		draw.Draw(paletted, paletted.Rect, f, bounds.Min, draw.Src)
+		palettedImgs = append(palettedImgs, paletted)
+	}
+
+	var delays = make([]int, numFrames)
+	var disposals []byte // unspecified unless the frames depend on it
+	if opts.Compact || opts.Transparent {
+		disposals = make([]byte, numFrames)
+	}
+	for i := 0; i < numFrames; i++ {
+		delays[i] = opts.Delay
+		switch {
+		case opts.Transparent:
+			disposals[i] = gif.DisposalBackground
+		case opts.Compact:
+			disposals[i] = gif.DisposalNone // compacted frames are drawn over the previous ones
+		}
+	}
+	delays[0] = opts.FirstDelay
+	delays[numFrames-1] = opts.FinalDelay
+
+	anim := gif.GIF{Delay: delays, Image: palettedImgs, Disposal: disposals, LoopCount: opts.Loop}
+	return gif.EncodeAll(contextWriter{ctx, w}, &anim)
+}
+
+// validateGIF decodes the GIF file at path and checks it holds as many frames as encoded, of the same size
+func validateGIF(path string, frames []image.Image) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	decoded, err := gif.DecodeAll(f)
+	if err != nil {
+		return err
+	}
+	if len(decoded.Image) != len(frames) {
+		return fmt.Errorf("decoded %d frames, encoded %d", len(decoded.Image), len(frames))
+	}
+	w, h := frames[0].Bounds().Dx(), frames[0].Bounds().Dy()
+	if decoded.Config.Width != w || decoded.Config.Height != h {
+		return fmt.Errorf("decoded a %dx%d GIF, encoded %dx%d", decoded.Config.Width, decoded.Config.Height, w, h)
+	}
+	return nil
+}
+
+// changedBounds returns the smallest rectangle holding every pixel that differs between frames a and b
+// frames of different sizes differ entirely, identical frames return a single pixel as GIF frames cannot be empty
+func changedBounds(a, b image.Image) image.Rectangle {
+	bounds := b.Bounds()
+	if a.Bounds() != bounds {
+		return bounds
+	}
+
+	changed := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if pixelChanged(a, b, x, y) {
+				changed = changed.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if changed.Empty() {
+		return image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+1, bounds.Min.Y+1)
+	}
+	return changed
+}
+
+// pixelChanged reports whether the pixel at x,y differs between images a and b
+func pixelChanged(a, b image.Image, x, y int) bool {
+	r1, g1, b1, a1 := a.At(x, y).RGBA()
+	r2, g2, b2, a2 := b.At(x, y).RGBA()
+	return r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2
+}
+
+// parseExtension validates the file extension passed to the --extension flag
+// a leading dot is optional
+func parseExtension(rawFlag string) (string, error) {
+	ext := strings.TrimPrefix(rawFlag, ".")
+	if ext == "" || len(ext) > 8 {
+		return "", fmt.Errorf("invalid extension %q, must be 1 to 8 characters", rawFlag)
+	}
+	for _, r := range ext {
+		if !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return "", fmt.Errorf("invalid extension %q, must be alphanumeric", rawFlag)
+		}
+	}
+	return ext, nil
+}
+
+// outputPath splits the --out-dir flag into the output directory and the name of the GIF, without extension
+// a flag ending in the extension of the GIF is the full path of the file, otherwise the GIF is named after the user
+func outputPath(rawFlag, userHandle, ext string) (dir, name string, isFile bool) {
+	base := filepath.Base(rawFlag)
+	if len(base) <= len(ext)+1 || !strings.EqualFold(filepath.Ext(base), "."+ext) {
+		return rawFlag, userHandle, false
+	}
+	return filepath.Dir(rawFlag), base[:len(base)-len(ext)-1], true
+}
+
+// checkWritable returns an error unless dir is an existing directory in which files can be created
+func checkWritable(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := ioutil.TempFile(dir, ".gifhub-")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// ensureDir creates the output directory if it does not exist yet
+func ensureDir(outputDir string) error {
+	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
+		return os.Mkdir(outputDir, os.ModePerm)
+	}
+	return nil
+}
+
+// runFolderLayout is the timestamp layout of the run folders, without colons so it is a valid path on Windows
+const runFolderLayout = "2006-01-02T15-04-05"
+
+// runFolder creates and returns a folder inside outputDir named after the time t
+func runFolder(outputDir string, t time.Time) (string, error) {
+	dir := filepath.Join(outputDir, t.Format(runFolderLayout))
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("run folder: %v", err)
+	}
+	return dir, nil
+}
+
+// ErrBlocked is returned when GitHub, or a proxy in between, answers with an anti-bot challenge
+// instead of the requested page
+var ErrBlocked = errors.New("blocked by an anti-bot challenge page, the requests may be rate limited: wait before retrying")
+
+// ErrYearsUnparsed is returned when the year links of a GitHub homepage are found but no year
+// could be extracted from any of them, which hints at a change of markup rather than a user without activity
+var ErrYearsUnparsed = errors.New("found the year links but could not parse any year, the markup may have changed")
+
+// interstitialMarkers are tokens found in anti-bot challenge pages
+var interstitialMarkers = [][]byte{
+	[]byte("Checking your browser"),
+	[]byte("Just a moment..."),
+	[]byte("challenge-platform"),
+	[]byte("cf-challenge"),
+	[]byte("cf_chl_"),
+}
+
+// fetchOptions contains the settings used to GET and scrape pages from GitHub
+type fetchOptions struct {
+	MaxBytes      int64        // largest response body accepted
+	Backoff       *backoff     // shared by all the requests of a run
+	StrictMarkup  bool         // fail instead of using fallback scraping strategies
+	MarkupVersion string       // name of the markupVersion to scrape, or auto
+	Metrics       *metrics     // nil to not record requests
+	Client        *http.Client // shared by all the requests of a run, nil for http.DefaultClient
+	Token         string       // GitHub personal access token, empty for anonymous requests
+	Retries       int          // times a request failing transiently is retried
+	BaseURL       string       // of a GitHub Enterprise instance, such as https://github.example.com, empty for GitHub
+}
+
+// githubURL is the base URL of GitHub, as opposed to that of a GitHub Enterprise instance
+const githubURL = "https://github.com"
+
+// webURL returns the URL of path on the web interface of the GitHub instance of opts
+func (opts fetchOptions) webURL(path string) string {
+	if opts.BaseURL == "" {
+		return githubURL + path
+	}
+	return opts.BaseURL + path
+}
+
+// apiURL returns the URL of path on the REST API of the GitHub instance of opts, served under /api/v3 by GitHub Enterprise
+func (opts fetchOptions) apiURL(path string) string {
+	if opts.BaseURL == "" || opts.BaseURL == githubURL {
+		return "https://api.github.com" + path
+	}
+	return opts.BaseURL + "/api/v3" + path
+}
+
+// parseHost returns the base URL of the GitHub instance at host, such as github.example.com or http://localhost:8080
+// a host without scheme is served over HTTPS
+func parseHost(host string) (string, error) {
+	raw := host
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("invalid host %q: %v", host, err)
+	case u.Scheme != "https" && u.Scheme != "http":
+		return "", fmt.Errorf("invalid host %q, must be served over http or https", host)
+	case u.Host == "" || u.User != nil || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "":
+		return "", fmt.Errorf("invalid host %q, must be a hostname such as github.example.com", host)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// newClient returns an HTTP client whose connections are reused across requests unless keepAlive is false
+// its requests fail after timeout, 0 for no timeout
+func newClient(keepAlive bool, timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = !keepAlive
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
+
+// retryBackoff is the pause before the first retry of a request, doubled on every following retry
+const retryBackoff = time.Second
+
+// backoff coordinates concurrent requests so that once GitHub rate limits one,
+// all of them pause for the Retry-After window instead of retrying in a thundering herd
+type backoff struct {
+	mu          sync.Mutex
+	pausedUntil time.Time
+}
+
+// wait blocks until the requests are no longer paused
+func (b *backoff) wait() {
+	b.mu.Lock()
+	d := time.Until(b.pausedUntil)
+	b.mu.Unlock()
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+// pause holds back all requests for d, unless they are already paused for longer
+func (b *backoff) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until := time.Now().Add(d); until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
+
+// retryAfter returns the pause requested by a Retry-After header in seconds or HTTP-date format
+// or fallback if the header is missing or invalid
+func retryAfter(header string, fallback time.Duration) time.Duration {
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return fallback
+}
+
+// transient reports whether a request error may not happen again on a retry, such as a timeout or a dropped connection
+func transient(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// html GETs the HTML text of a URL
+func html(url string, opts fetchOptions) ([]byte, error) {
+	body, _, err := fetch(url, opts)
+	return body, err
+}
+
+// fetch GETs the HTML text of a URL, along with the final URL after following redirects
+// transient errors and 429 or 5xx statuses are retried up to opts.Retries times, with exponential backoff
+// unless the response has a Retry-After header. Rate limited requests are retried once all requests
+// sharing opts.Backoff stop being paused
+func fetch(url string, opts fetchOptions) (body []byte, finalURL string, err error) {
+	return request("GET", url, nil, opts)
+}
+
+// request sends a request with the method and payload to url and returns the body of the response,
+// along with the final URL after following redirects, retrying it as fetch does
+func request(method, url string, payload []byte, opts fetchOptions) (body []byte, finalURL string, err error) {
+	b := opts.Backoff
+	if b == nil {
+		b = &backoff{}
+	}
+
+	var res *http.Response
+	for attempt := 0; ; attempt++ {
+		b.wait()
+		res, err = send(method, url, payload, opts.Client, opts.Token)
+		opts.Metrics.request(err)
+		retry := attempt < opts.Retries
+		d := retryBackoff << uint(attempt)
+		if err != nil {
+			if !retry || !transient(err) {
+				return nil, "", err
+			}
+			logger.Printf("%s: %v: retrying in %v\n", method, err, d)
+			time.Sleep(d)
+			continue
+		}
+		rateLimited := res.StatusCode == http.StatusTooManyRequests
+		if !retry || !rateLimited && res.StatusCode < 500 {
+			break
+		}
+		// drain the body so the connection can be reused for the retry
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		d = retryAfter(res.Header.Get("Retry-After"), d)
+		if rateLimited {
+			logger.Printf("%s status: %s: pausing requests for %v\n", method, res.Status, d)
+			b.pause(d)
+		} else {
+			logger.Printf("%s status: %s: retrying in %v\n", method, res.Status, d)
+			time.Sleep(d)
+		}
+	}
+
+	defer func() {
+		cerr := res.Body.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	if res.StatusCode != 200 {
+		return nil, "", statusError{method, url, res.Status, res.StatusCode}
+	}
+
+	// read one byte past the limit to tell a body of exactly MaxBytes from a larger one
+	body, err = ioutil.ReadAll(io.LimitReader(res.Body, opts.MaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(body)) > opts.MaxBytes {
+		return nil, "", fmt.Errorf("%s body: larger than %d bytes: %s", method, opts.MaxBytes, url)
+	}
+	return body, res.Request.URL.String(), nil
+}
+
+// statusError is returned for a response whose status is not 200 OK
+type statusError struct {
+	Method, URL, Status string
+	Code                int
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("%s status: %s: %s", e.Method, e.Status, e.URL)
+}
+
+// send issues a single request with the method and payload to url with client
+// an empty token issues an anonymous request, a nil payload a request without body
+func send(method, url string, payload []byte, client *http.Client, token string) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set(
+		"User-Agent",
+		"gifhub v0.0 https://www.github.com/camilogarcialarotta/gifhub - This bot generates GIFs from the user's yearly activity graph",
+	)
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// interstitial reports whether the HTML text is an anti-bot challenge page
+// it is only consulted once the expected markup is missing, as profiles may contain the markers
+func interstitial(html []byte) bool {
+	for _, marker := range interstitialMarkers {
+		if bytes.Contains(html, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// img generates an image from graph values g with the styles defined in s
+func img(g graph, s style) image.Image {
+	// to reduce cognitive load, unpack most used variables
+	w := g.Coords.W
+	h := g.Coords.H
+	mid := g.Coords.Mid
+	midY := g.Coords.MidY
+	graphH := g.Coords.GraphH
+	factor := g.Coords.Factor
+	axisMargin := g.Coords.AxisMargin
+
+	dc := gg.NewContext(int(w), int(h))
+	if !s.Transparent {
+		dc.SetColor(color.White)
+		dc.Clear()
+	}
+	if s.BgGradient != nil {
+		gradient := gg.NewLinearGradient(0, 0, 0, h)
+		gradient.AddColorStop(0, s.BgGradient[0])
+		gradient.AddColorStop(1, s.BgGradient[1])
+		dc.SetFillStyle(gradient)
+		dc.DrawRectangle(0, 0, w, h)
+		dc.Fill()
+	}
+
+	// the graph is graphH high, the remaining strip holds the handle and year labels
+	var graphY, labelY float64
+	labelColor := s.LabelColor
+	switch s.LabelPos {
+	case "top":
+		graphY = h - graphH
+		labelY = 0.75 * factor
+	case "overlay":
+		graphY = (h - graphH) / 2
+		labelY = graphY + midY - 0.25*factor
+		r, g, b, _ := s.LabelColor.RGBA()
+		labelColor = color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0x80}
+	default:
+		labelY = h - 1.25*factor
+	}
+
+	if s.Rewind {
+		rewindCue(factor, s, dc)
+	}
+
+	// upright labels are rotated back about their anchor, so only their position turns with the chart
+	drawText := func(text string, x, y float64) {
+		if g.Rotation == 0 || !s.UprightLabels {
+			dc.DrawStringAnchored(text, x, y, 0.5, 0.5)
+			return
+		}
+		dc.Push()
+		dc.RotateAbout(-g.Rotation, x, y)
+		dc.DrawStringAnchored(text, x, y, 0.5, 0.5)
+		dc.Pop()
+	}
+	format := s.FormatValue
+	if format == nil {
+		format = formatValue
+	}
+
+	dc.Push()
+	dc.Translate(0, graphY)
+	if g.Rotation != 0 {
+		dc.RotateAbout(g.Rotation, mid, midY)
+	}
+
+	// draw the grid faintly, underneath the polygon: a ring joins the same percentage on the four axes
+	if s.Grid {
+		r, gr, b, _ := s.AxisColor.RGBA()
+		dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(gr >> 8), uint8(b >> 8), 0x50})
+		dc.SetLineWidth(factor * 0.03)
+		// the ticks are along the vertical axis, the horizontal one is as long unless the chart is not square
+		ratio := (mid - axisMargin) / (midY - axisMargin)
+		for _, d := range g.Coords.Ticks {
+			dc.MoveTo(mid, midY-d)
+			dc.LineTo(mid+d*ratio, midY)
+			dc.LineTo(mid, midY+d)
+			dc.LineTo(mid-d*ratio, midY)
+			dc.ClosePath()
+			dc.Stroke()
+		}
+	}
+
+	// draw the baseline faintly, underneath the polygon
+	if g.Baseline != nil {
+		drawBaseline(*g.Baseline, s, dc)
+	}
+
+	// without contributions the polygon collapses to the origin, a message takes the place of the chart
+	// the legend still lists the metrics, so that it does not come and go between the frames
+	if len(g.Series) == 0 && noActivity(g.Data) {
+		if !s.NoLabels {
+			dc.SetFontFace(s.LabelFont)
+			dc.SetColor(s.ValueColor)
+			drawText("No activity", mid, midY)
+		}
+		dc.Pop()
+		if !s.NoLabels {
+			if s.Legend {
+				drawMetricLegend(g, func(_ string, c color.Color) color.Color { return c }, format, graphY, s, dc)
+			}
+			drawCaption(g.Data, s, labelColor, mid, labelY, factor, dc)
+		}
+		return dc.Image()
+	}
+
+	// draw polygon
+	if len(g.Series) > 0 {
+		drawSeries(g.Series, s, dc)
+	} else {
+		dc.SetColor(s.PolyColor)
+		dc.SetLineWidth(factor / 5)
+		if s.Smooth {
+			spline(g.Coords, dc)
+		} else {
+			polygon(g.Coords, dc)
+		}
+		dc.StrokePreserve()
+		dc.Fill()
+	}
+
+	// the largest metrics are drawn in the highlight color, overlaid users have no single largest metric
+	highlighted := map[string]bool{}
+	if s.HighlightColor != nil && len(g.Series) == 0 {
+		highlighted = largestMetrics(g.Data)
+	}
+	metricColor := func(metric string, c color.Color) color.Color {
+		if highlighted[metric] {
+			return s.HighlightColor
+		}
+		return c
+	}
+
+	// draw axis, one half per metric from the origin to its end
+	// the horizontal axis spans the width of the chart, the vertical one its height
+	dc.SetLineWidth(factor * 0.08)
+	left, right, top, bottom := axisMargin, w-axisMargin, axisMargin, graphH-axisMargin
+	if s.AxisExtent == "edge" {
+		left, right, top, bottom = 0, w, 0, graphH
+	}
+	halfAxes := []struct {
+		metric         string
+		x1, y1, x2, y2 float64
+	}{
+		{"codeReviews", mid, top, mid, midY},
+		{"issues", mid, midY, right, midY},
+		{"prs", mid, midY, mid, bottom},
+		{"commits", left, midY, mid, midY},
+	}
+	if s.AxisExtent == "to-vertex" {
+		halfAxes[0].y1 = g.Coords.CodeReviewY
+		halfAxes[1].x2 = g.Coords.IssuesX
+		halfAxes[2].y2 = g.Coords.PrsY
+		halfAxes[3].x1 = g.Coords.CommitsX
+	}
+	for _, a := range halfAxes {
+		dc.SetColor(metricColor(a.metric, s.AxisColor))
+		dc.DrawLine(a.x1, a.y1, a.x2, a.y2)
+		dc.Stroke()
+	}
+
+	// draw scale ticks, faint so they do not compete with the values
+	if s.ScaleTicks {
+		r, gr, b, _ := s.ValueColor.RGBA()
+		dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(gr >> 8), uint8(b >> 8), 0x80})
+		dc.SetLineWidth(factor * 0.04)
+		dc.SetFontFace(s.TickFont)
+		for i, p := range tickPercents {
+			y := midY - g.Coords.Ticks[i]
+			dc.DrawLine(mid-0.1*factor, y, mid+0.1*factor, y)
+			dc.Stroke()
+			if !s.NoLabels {
+				drawText(fmt.Sprintf("%d%%", p), mid+0.45*factor, y)
+			}
+		}
+	}
+
+	if s.OriginColor != nil {
+		dc.SetColor(s.OriginColor)
+		dc.DrawCircle(mid, midY, s.MarkerRadius)
+		dc.Fill()
+	}
+
+	// draw circles
+	drawSeriesMarkers(g.Series, s, dc)
+	if g.Data.CodeReviews > 0 {
+		circle(metricColor("codeReviews", s.AxisColor), color.White, s.MarkerRadius, mid, g.Coords.CodeReviewY, dc)
+	}
+	if g.Data.Issues > 0 {
+		circle(metricColor("issues", s.AxisColor), color.White, s.MarkerRadius, g.Coords.IssuesX, midY, dc)
+	}
+	if g.Data.Prs > 0 {
+		circle(metricColor("prs", s.AxisColor), color.White, s.MarkerRadius, mid, g.Coords.PrsY, dc)
+	}
+	if g.Data.Commits > 0 {
+		circle(metricColor("commits", s.AxisColor), color.White, s.MarkerRadius, g.Coords.CommitsX, midY, dc)
+	}
+
+	if s.NoLabels {
+		dc.Pop()
+		return dc.Image()
+	}
+
+	// the values of overlaid users would overlap, the legend tells them apart instead
+	legend := s.Legend && len(g.Series) == 0
+
+	// draw text, the legend lists the metrics and values instead
+	if !legend {
+		dc.SetFontFace(s.LabelFont)
+		dc.SetColor(metricColor("codeReviews", s.LabelColor))
+		drawText("Code Review", mid, 1.5*factor)
+		dc.SetColor(metricColor("issues", s.LabelColor))
+		drawText("Issues", w-1.25*factor, midY+0.25*factor)
+		dc.SetColor(metricColor("prs", s.LabelColor))
+		drawText("Pull Requests", mid, graphH-1.25*factor)
+		dc.SetColor(metricColor("commits", s.LabelColor))
+		drawText("Commits", 1.25*factor, midY+0.25*factor)
+	}
+
+	dc.SetFontFace(s.ValueFont)
+	dc.SetColor(s.ValueColor)
+	if len(g.Series) == 0 && !legend {
+		drawText(format("codeReviews", 0, g.Data.CodeReviews), mid, factor)
+		drawText(format("issues", 0, g.Data.Issues), w-1.25*factor, midY-0.25*factor)
+		drawText(format("prs", 0, g.Data.Prs), mid, graphH-1.75*factor)
+		drawText(format("commits", 0, g.Data.Commits), 1.25*factor, midY-0.25*factor)
+	}
+
+	if g.Prev != nil && !legend {
+		delta(g.Data.CodeReviews-g.Prev.CodeReviews, mid+factor, factor, s, dc)
+		delta(g.Data.Issues-g.Prev.Issues, w-1.25*factor, midY-0.75*factor, s, dc)
+		delta(g.Data.Prs-g.Prev.Prs, mid+factor, graphH-1.75*factor, s, dc)
+		delta(g.Data.Commits-g.Prev.Commits, 1.25*factor, midY-0.75*factor, s, dc)
+	}
+
+	dc.Pop()
+
+	if legend {
+		drawMetricLegend(g, metricColor, format, graphY, s, dc)
+	}
+
+	caption := s
+	caption.ShowStreak = s.ShowStreak && len(g.Series) == 0 // overlaid users have no single streak
+	drawCaption(g.Data, caption, labelColor, mid, labelY, factor, dc)
+	if len(g.Series) > 0 {
+		drawSeriesLegend(g.Series, h, factor, s, dc)
+	}
+
+	return dc.Image()
+}
+
+// drawMetricLegend draws every metric of g with its value, and its change with g.Prev, next to a marker of its color
+// in the top right corner of the chart drawn at graphY. colorOf returns the color of a metric, c unless it is highlighted
+func drawMetricLegend(g graph, colorOf func(metric string, c color.Color) color.Color, format valueFormatter, graphY float64, s style, dc *gg.Context) {
+	factor := g.Coords.Factor
+	rows := []struct {
+		metric, name string
+		pct, prev    int
+	}{
+		{"codeReviews", "Code Review", g.Data.CodeReviews, 0},
+		{"issues", "Issues", g.Data.Issues, 0},
+		{"prs", "Pull Requests", g.Data.Prs, 0},
+		{"commits", "Commits", g.Data.Commits, 0},
+	}
+	if g.Prev != nil {
+		rows[0].prev, rows[1].prev, rows[2].prev, rows[3].prev = g.Prev.CodeReviews, g.Prev.Issues, g.Prev.Prs, g.Prev.Commits
+	}
+
+	dc.SetFontFace(s.ValueFont)
+	nameW, valueW := 0.0, 0.0
+	for _, r := range rows {
+		if w, _ := dc.MeasureString(r.name); w > nameW {
+			nameW = w
+		}
+		if w, _ := dc.MeasureString(format(r.metric, 0, r.pct)); w > valueW {
+			valueW = w
+		}
+	}
+	deltaW := 0.0
+	if g.Prev != nil {
+		deltaW = 1.7 * factor
+	}
+
+	// marker, name and value columns, right aligned to the corner
+	right := g.Coords.W - 0.3*factor - deltaW
+	valueX := right
+	nameX := valueX - valueW - 0.3*factor - nameW
+	markerX := nameX - 0.35*factor
+	for i, r := range rows {
+		y := graphY + 0.5*factor + float64(i)*0.45*factor
+		circle(colorOf(r.metric, s.AxisColor), color.White, s.MarkerRadius*0.8, markerX, y, dc)
+		dc.SetFontFace(s.ValueFont)
+		dc.SetColor(colorOf(r.metric, s.LabelColor))
+		dc.DrawStringAnchored(r.name, nameX, y, 0, 0.5)
+		dc.SetColor(s.ValueColor)
+		dc.DrawStringAnchored(format(r.metric, 0, r.pct), valueX, y, 1, 0.5)
+		if g.Prev != nil {
+			delta(r.pct-r.prev, right+deltaW/2+0.1*factor, y, s, dc)
+		}
+	}
+}
+
+// noActivity reports whether act has no contributions of any kind
+func noActivity(act activity) bool {
+	return act.Commits == 0 && act.Issues == 0 && act.Prs == 0 && act.CodeReviews == 0
+}
+
+// drawCaption draws the handle and year labels of act centered at mid,labelY, followed by the streak with s.ShowStreak
+func drawCaption(act activity, s style, labelColor color.Color, mid, labelY, factor float64, dc *gg.Context) {
+	dc.SetFontFace(s.LabelFont)
+	dc.SetColor(labelColor)
+	lines := strings.Split(expandLabel(s.LabelTemplate, act), "\n")
+	for i, line := range lines {
+		dc.DrawStringAnchored(line, mid, labelY+float64(i)*0.5*factor, 0.5, 0.5)
+	}
+
+	if s.ShowStreak {
+		dc.SetFontFace(s.ValueFont)
+		dc.SetColor(s.ValueColor)
+		dc.DrawStringAnchored("Longest streak: "+streakLabel(act.Streak), mid, labelY+float64(len(lines))*0.5*factor, 0.5, 0.5)
+	}
+}
+
+// deltaUpColor and deltaDownColor are the colors of the increases and decreases drawn by delta
+var (
+	deltaUpColor   = color.RGBA{40, 167, 69, 0xff}
+	deltaDownColor = color.RGBA{203, 36, 49, 0xff}
+)
+
+// delta draws an arrow pointing up or down followed by the signed change d centered at x,y
+// nothing is drawn if there was no change
+func delta(d int, x, y float64, s style, dc *gg.Context) {
+	if d == 0 {
+		return
+	}
+
+	arrowColor, dir := deltaUpColor, -1.0
+	if d < 0 {
+		arrowColor, dir = deltaDownColor, 1.0
+	}
+	text := fmt.Sprintf("%+d%%", d)
+	textW, _ := dc.MeasureString(text)
+	size := s.MarkerRadius * 1.5
+	left := x - (size+textW+size/2)/2
+
+	dc.SetColor(arrowColor)
+	dc.MoveTo(left, y-dir*size/2)
+	dc.LineTo(left+size, y-dir*size/2)
+	dc.LineTo(left+size/2, y+dir*size/2)
+	dc.ClosePath()
+	dc.Fill()
+	dc.DrawStringAnchored(text, left+size*1.5, y, 0, 0.5)
+}
+
+// rewindCue draws a rewind sign and caption in the top left corner, faint so it does not compete with the graph
+// with --no-labels only the sign is drawn
+func rewindCue(factor float64, s style, dc *gg.Context) {
+	r, g, b, _ := s.ValueColor.RGBA()
+	dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0xa0})
+
+	// two triangles pointing left, as on the rewind button of a player
+	size := 0.24 * factor
+	x, y := 0.3*factor, 0.5*factor
+	for i := 0.0; i < 2; i++ {
+		left := x + i*size
+		dc.MoveTo(left, y)
+		dc.LineTo(left+size, y-size/2)
+		dc.LineTo(left+size, y+size/2)
+		dc.ClosePath()
+		dc.Fill()
+	}
+	if s.NoLabels {
+		return
+	}
+	dc.SetFontFace(s.TickFont)
+	dc.DrawStringAnchored("rewinding", x+2.5*size, y, 0, 0.4)
+}
+
+// highlightColor is the accent color of the largest metric drawn by --highlight-max
+var highlightColor = color.RGBA{227, 98, 9, 0xff}
+
+// largestMetrics returns the names of the metrics with the largest non-zero percentage of the activity
+// all of them in case of a tie
+func largestMetrics(a activity) map[string]bool {
+	metrics := map[string]int{
+		"codeReviews": a.CodeReviews,
+		"issues":      a.Issues,
+		"prs":         a.Prs,
+		"commits":     a.Commits,
+	}
+	largest := 0
+	for _, pct := range metrics {
+		if pct > largest {
+			largest = pct
+		}
+	}
+	names := map[string]bool{}
+	for name, pct := range metrics {
+		if largest > 0 && pct == largest {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// baselineFillAlpha is the opacity of the fill of the --baseline-year polygon
+const baselineFillAlpha = 0x40
+
+// drawBaseline draws the baseline polygon c faintly in the polygon color of s
+// with s.DitherFill the translucent fill is dithered instead of blended, as the overlaid polygons are
+func drawBaseline(c coords, s style, dc *gg.Context) {
+	r, g, b, _ := s.PolyColor.RGBA()
+	if s.DitherFill {
+		dc.SetFillStyle(ditherPattern{s.PolyColor, baselineFillAlpha / 255.0})
+	} else {
+		dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), baselineFillAlpha})
+	}
+	if s.Smooth {
+		spline(c, dc)
+	} else {
+		polygon(c, dc)
+	}
+	dc.FillPreserve()
+	dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 0x90})
+	dc.SetLineWidth(c.Factor * 0.04)
+	dc.Stroke()
+}
+
+// polygon adds the path of the activity polygon described by c to the image context
+func polygon(c coords, dc *gg.Context) {
+	dc.MoveTo(c.Mid, c.CodeReviewY)
+	dc.LineTo(c.IssuesX, c.MidY)
+	dc.LineTo(c.Mid, c.PrsY)
+	dc.LineTo(c.CommitsX, c.MidY)
+	dc.ClosePath()
+}
+
+// spline adds the path of a closed Catmull-Rom spline through the vertices of
+// the activity polygon described by c to the image context
+func spline(c coords, dc *gg.Context) {
+	vertices := []gg.Point{
+		{X: c.Mid, Y: c.CodeReviewY},
+		{X: c.IssuesX, Y: c.MidY},
+		{X: c.Mid, Y: c.PrsY},
+		{X: c.CommitsX, Y: c.MidY},
+	}
+	n := len(vertices)
+
+	// every segment between p1 and p2 is the cubic Bezier curve whose control points are
+	// offset along the tangents, which Catmull-Rom defines by the neighbouring vertices p0 and p3
+	dc.MoveTo(vertices[0].X, vertices[0].Y)
+	for i := range vertices {
+		p0 := vertices[(i+n-1)%n]
+		p1 := vertices[i]
+		p2 := vertices[(i+1)%n]
+		p3 := vertices[(i+2)%n]
+		dc.CubicTo(
+			p1.X+(p2.X-p0.X)/6, p1.Y+(p2.Y-p0.Y)/6,
+			p2.X-(p3.X-p1.X)/6, p2.Y-(p3.Y-p1.Y)/6,
+			p2.X, p2.Y,
+		)
+	}
+	dc.ClosePath()
+}
+
+// formatValue is the default valueFormatter, it renders the percentage of the metric
+func formatValue(metric string, raw, pct int) string {
+	return fmt.Sprintf("%d%%", pct)
+}
+
+// defaultLabelTemplate is the caption of GitHub's activity overview graph, the handle above the year
+const defaultLabelTemplate = "{handle}\n{year}"
+
+// labelPlaceholder matches the {field} placeholders of a label template
+var labelPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// labelFields returns the values of the label template placeholders for activity a
+func labelFields(a activity) map[string]string {
+	streak := strconv.Itoa(a.Streak)
+	if a.Streak == unknownStreak {
+		streak = "unknown"
+	}
+	return map[string]string{
+		"handle":      a.Handle,
+		"year":        yearLabel(a.Year),
+		"commits":     strconv.Itoa(a.Commits),
+		"issues":      strconv.Itoa(a.Issues),
+		"prs":         strconv.Itoa(a.Prs),
+		"codeReviews": strconv.Itoa(a.CodeReviews),
+		"streak":      streak,
+	}
+}
+
+// parseLabelTemplate validates the template passed to the --label-template flag
+// the escape sequence \n is replaced by a line break
+func parseLabelTemplate(rawFlag string) (string, error) {
+	tmpl := strings.Replace(rawFlag, `\n`, "\n", -1)
+	fields := labelFields(activity{})
+	for _, match := range labelPlaceholder.FindAllStringSubmatch(tmpl, -1) {
+		if _, ok := fields[match[1]]; !ok {
+			names := make([]string, 0, len(fields))
+			for name := range fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("unknown placeholder %s, must be one of {%s}", match[0], strings.Join(names, "}, {"))
+		}
+	}
+	return tmpl, nil
+}
+
+// expandLabel replaces the placeholders of the template tmpl with the fields of activity a
+// unknown placeholders are kept as is
+func expandLabel(tmpl string, a activity) string {
+	fields := labelFields(a)
+	return labelPlaceholder.ReplaceAllStringFunc(tmpl, func(match string) string {
+		if value, ok := fields[match[1:len(match)-1]]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// circle creates a circle with outer radius r and inner radius r/2
+// in the x,y coordinates of the image context
+func circle(outerColor, innerColor color.Color, r, x, y float64, dc *gg.Context) {
+	dc.SetColor(innerColor)
+	dc.DrawCircle(x, y, r)
+	dc.FillPreserve()
+	dc.SetColor(outerColor)
+	dc.SetLineWidth(r / 2)
+	dc.Stroke()
+}
+
+// activityURL returns the URL of the activity overview of a GitHub user on a given year, or range of months
+// on the GitHub instance at base
+func activityURL(base, userHandle, year string) string {
+	from, to := yearDates(year)
+	return fmt.Sprintf("%s/%s?tab=overview&from=%s&to=%s", base, userHandle, from, to)
+}
+
+// parseActivity returns an activity for a GitHub user on a given year, or range of months
+func parseActivity(userHandle, year string, opts fetchOptions) (activity, error) {
+	url := activityURL(opts.webURL(""), userHandle, year)
+	body, finalURL, err := fetch(url, opts)
+	if err != nil {
+		return activity{}, err
+	}
+
+	a, err := scrapeActivity(body, opts.MarkupVersion, opts.StrictMarkup)
+	if err != nil {
+		return activity{}, err
+	}
+
+	// a handle in another casing redirects to the profile, which labels the activity with its casing
+	// any other redirect, such as to a login page, keeps the handle as given
+	if canonical := profileHandle(finalURL); canonical != userHandle && strings.EqualFold(canonical, userHandle) {
+		logger.Printf("%s redirected to %s, labeling %s with its casing\n", userHandle, canonical, year)
+		userHandle = canonical
+	}
+	a.Handle = userHandle
+	a.Year = year
+
+	return a, nil
+}
+
+// profileHandle returns the handle of a GitHub profile URL, or "" if it is not a profile URL
+func profileHandle(profileURL string) string {
+	u, err := url.Parse(profileURL)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) != 1 {
+		return ""
+	}
+	return segments[0]
+}
+
+// markupVersion is a named strategy to extract the activity container from a GitHub homepage HTML text
+type markupVersion struct {
+	Name                     string
+	ActivityAttr, ClosingTag []byte
+}
+
+// markupVersions are the known strategies, tried in order when the version is auto
+var markupVersions = []markupVersion{
+	// double quoted attribute, the JSON quotes are escaped as &quot;
+	{"2023", []byte("data-percentages=\""), []byte("\">")},
+	// single quoted attribute, the JSON quotes are not escaped
+	{"2024", []byte("data-percentages='"), []byte("'")},
+}
+
+// markupVersionNames returns the valid values of the --markup-version flag
+func markupVersionNames() []string {
+	names := []string{"auto"}
+	for _, v := range markupVersions {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// scrapeActivity returns an activity from a GitHub homepage HTML text
+// extracted with the given markup version, or the first one that matches if version is auto
+// if strict is set, falling back to any but the first version is an error
+func scrapeActivity(html []byte, version string, strict bool) (activity, error) {
+	activity := activity{} // the struct to return
+
+	// keys of the JSON percentages, in a fixed order so errors do not vary between runs
+	activityKeys := []struct {
+		key   string
+		value *int
+	}{
+		{"Code review", &activity.CodeReviews},
+		{"Commits", &activity.Commits},
+		{"Issues", &activity.Issues},
+		{"Pull requests", &activity.Prs},
+	}
+
+	quoteUnicode := []byte("&quot;")
+	quote := []byte("\"")
+
+	// extract the activity container from the HTML text
+	var rawActivity []byte
+	var err error
+	for i, v := range markupVersions {
+		if version != "auto" && version != v.Name {
+			continue
+		}
+		var vErr error
+		if rawActivity, vErr = extractBetween(html, v.ActivityAttr, v.ClosingTag); vErr == nil {
+			if i > 0 && version == "auto" && strict {
+				return activity, markupDeviation(fmt.Sprintf("fell back to markup version %s", v.Name))
+			}
+			break
+		}
+		if err == nil {
+			err = vErr // report why the primary markup did not match
+		}
+	}
+	if rawActivity == nil {
+		if err == nil {
+			return activity, fmt.Errorf("unknown markup version %q", version)
+		}
+		if interstitial(html) {
+			return activity, ErrBlocked
+		}
+		return activity, err
+	}
+
+	// the percentages are a JSON object, HTML-escaped inside double-quoted attributes
+	// the values are parsed separately as they may be quoted or padded
+	var percentages map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.Replace(rawActivity, quoteUnicode, quote, -1), &percentages); err != nil {
+		return activity, fmt.Errorf("activity percentages: %v", err)
+	}
+	for _, k := range activityKeys {
+		raw, ok := percentages[k.key]
+		if !ok {
+			return activity, fmt.Errorf("activity percentages: did not find %q in %s", k.key, rawActivity)
+		}
+		num, err := parseScrapedInt(raw)
+		if err != nil {
+			return activity, fmt.Errorf("activity percentages: %s: %v", k.key, err)
+		}
+		*k.value = num
+	}
+
+	return activity, nil
+}
+
+// parseYearFlag returns the years passed to the -y flag
+// if no flag is passed, it defaults to all years
+func parseYearFlag(rawFlag, handle string, opts fetchOptions) ([]string, error) {
+	if rawFlag == "all" {
+		body, err := html(opts.webURL("/"+handle), opts)
+		if err != nil {
+			return nil, fmt.Errorf("parse year flag: %v", err)
+		}
+
+		years, err := scrapeYears(body, opts.StrictMarkup)
+		if err == nil && len(years) == 0 {
+			return nil, fmt.Errorf("parse year flag: no years available, %s has no activity", handle)
+		}
+		return years, err
+	}
+	if strings.HasPrefix(rawFlag, "@") {
+		return readYears(rawFlag[1:])
+	}
+
+	cleanYearFlag := strings.Trim(rawFlag, ", ")
+	return expandYears(strings.Split(cleanYearFlag, ","))
+}
+
+// readYears returns the years listed in a file, separated by commas or lines
+func readYears(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read years: %v", err)
+	}
+	entries := strings.FieldsFunc(string(content), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("read years: no years in %s", path)
+	}
+	years, err := expandYears(entries)
+	if err != nil {
+		return nil, fmt.Errorf("read years from %s: %v", path, err)
+	}
+	for _, year := range years {
+		if _, err := strconv.Atoi(year); err != nil && !monthRange.MatchString(year) {
+			return nil, fmt.Errorf("read years from %s: invalid year %q", path, year)
+		}
+	}
+	return years, nil
+}
+
+// expandYears replaces the ranges of years in the entries, such as 2016-2019, by every year they span
+// ranges of months, such as 2021-03:2021-09, are kept as a single entry
+func expandYears(entries []string) ([]string, error) {
+	years := []string{}
+	for _, entry := range entries {
+		if strings.Contains(entry, ":") {
+			if err := checkMonthRange(entry); err != nil {
+				return nil, err
+			}
+			years = append(years, entry)
+			continue
+		}
+		bounds := strings.Split(entry, "-")
+		if len(bounds) != 2 || len(bounds[0]) != 4 || len(bounds[1]) != 4 {
+			years = append(years, entry)
+			continue
+		}
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("parse year range %q: %v", entry, err)
+		}
+		to, err := strconv.Atoi(bounds[1])
+		if err != nil {
+			return nil, fmt.Errorf("parse year range %q: %v", entry, err)
+		}
+		if from > to {
+			return nil, fmt.Errorf("parse year range %q: %d is after %d", entry, from, to)
+		}
+		for year := from; year <= to; year++ {
+			years = append(years, strconv.Itoa(year))
+		}
+	}
+	return years, nil
+}
+
+// monthRange matches a range of months of the --years flag, such as 2021-03:2021-09
+var monthRange = regexp.MustCompile(`^(\d{4}-\d{2}):(\d{4}-\d{2})$`)
+
+// checkMonthRange validates a range of months such as 2021-03:2021-09, the last month may not be before the first
+func checkMonthRange(entry string) error {
+	m := monthRange.FindStringSubmatch(entry)
+	if m == nil {
+		return fmt.Errorf("parse month range %q: expected YYYY-MM:YYYY-MM", entry)
+	}
+	from, err := time.Parse("2006-01", m[1])
+	if err != nil {
+		return fmt.Errorf("parse month range %q: %v", entry, err)
+	}
+	to, err := time.Parse("2006-01", m[2])
+	if err != nil {
+		return fmt.Errorf("parse month range %q: %v", entry, err)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("parse month range %q: %s is after %s", entry, m[1], m[2])
+	}
+	return nil
+}
+
+// yearDates returns the first and last days, as YYYY-MM-DD, of a year or of a range of months such as 2021-03:2021-09
+func yearDates(year string) (from, to string) {
+	m := monthRange.FindStringSubmatch(year)
+	if m == nil {
+		return year + "-01-01", year + "-12-31"
+	}
+	last, err := time.Parse("2006-01", m[2])
+	if err != nil {
+		return m[1] + "-01", m[2] + "-31"
+	}
+	return m[1] + "-01", last.AddDate(0, 1, -1).Format("2006-01-02")
+}
+
+// yearLabel returns the caption of a year, ranges of months are shortened to 2021 Mar–Sep, or 2021 Nov–2022 Feb across years
+func yearLabel(year string) string {
+	m := monthRange.FindStringSubmatch(year)
+	if m == nil {
+		return year
+	}
+	from, errFrom := time.Parse("2006-01", m[1])
+	to, errTo := time.Parse("2006-01", m[2])
+	switch {
+	case errFrom != nil || errTo != nil:
+		return year
+	case from.Equal(to):
+		return from.Format("2006 Jan")
+	case from.Year() == to.Year():
+		return from.Format("2006 Jan") + "–" + to.Format("Jan")
+	}
+	return from.Format("2006 Jan") + "–" + to.Format("2006 Jan")
+}
+
+// joinYear returns the year a GitHub user created their account
+func joinYear(handle string, opts fetchOptions) (int, error) {
+	body, err := html(opts.apiURL("/users/"+handle), opts)
+	if err != nil {
+		return 0, fmt.Errorf("join year: %v", err)
+	}
+	var user struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+	if err := json.Unmarshal(body, &user); err != nil {
+		return 0, fmt.Errorf("join year: %v", err)
+	}
+	if user.CreatedAt.IsZero() {
+		return 0, fmt.Errorf("join year: no creation date for %s", handle)
+	}
+	return user.CreatedAt.Year(), nil
+}
+
+// handlePattern matches the GitHub usernames: alphanumeric characters and single hyphens,
+// neither at the start nor at the end
+var handlePattern = regexp.MustCompile(`^[a-zA-Z0-9]+(-[a-zA-Z0-9]+)*$`)
+
+// maxHandleLength is the maximum length of a GitHub username
+const maxHandleLength = 39
+
+// ErrUserNotFound is the error of checkHandle when GitHub has no profile for the handle
+var ErrUserNotFound = errors.New("user not found")
+
+// checkHandle validates handle against the GitHub username rules, then checks GitHub has a profile for it
+// a request failing for another reason than a missing profile is only logged, so that cached runs work offline
+func checkHandle(handle string, opts fetchOptions) error {
+	switch {
+	case len(handle) > maxHandleLength:
+		return fmt.Errorf("invalid GitHub-username %q, must be at most %d characters", handle, maxHandleLength)
+	case !handlePattern.MatchString(handle):
+		return fmt.Errorf("invalid GitHub-username %q, must be alphanumeric characters and single hyphens, neither first nor last", handle)
+	}
+
+	opts.Retries = 0
+	_, _, err := request("HEAD", opts.webURL("/"+handle), nil, opts)
+	var status statusError
+	switch {
+	case errors.As(err, &status) && status.Code == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrUserNotFound, handle)
+	case err != nil:
+		logger.Printf("check handle: %v\n", err)
+	}
+	return nil
+}
+
+// canonicalHandle returns the handle of a GitHub user in the casing of their profile,
+// handles are case-insensitive so the one passed by the user may differ
+func canonicalHandle(handle string, opts fetchOptions) (string, error) {
+	body, err := html(opts.webURL("/"+handle), opts)
+	if err != nil {
+		return "", fmt.Errorf("canonical handle: %v", err)
+	}
+	canonical, err := extractBetween(body, []byte(`<meta property="profile:username" content="`), []byte(`"`))
+	if err != nil {
+		if interstitial(body) {
+			return "", ErrBlocked
+		}
+		return "", fmt.Errorf("canonical handle: %v", err)
+	}
+	if !strings.EqualFold(string(canonical), handle) {
+		return "", fmt.Errorf("canonical handle: profile of %s is %s", handle, canonical)
+	}
+	return string(canonical), nil
+}
+
+// failures records the scrapes that failed, listed as "<year>" or as "<handle> <year>" with ShowHandle
+type failures struct {
+	ShowHandle bool
+	mu         sync.Mutex
+	scrapes    [][2]string
+}
+
+// add records the failed scrape of the activity of handle on year
+func (f *failures) add(handle, year string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scrapes = append(f.scrapes, [2]string{handle, year})
+}
+
+// list returns the failed scrapes in order of handle and year
+func (f *failures) list() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sort.Slice(f.scrapes, func(i, j int) bool {
+		if f.scrapes[i][0] != f.scrapes[j][0] {
+			return f.scrapes[i][0] < f.scrapes[j][0]
+		}
+		return f.scrapes[i][1] < f.scrapes[j][1]
+	})
+	list := make([]string, len(f.scrapes))
+	for i, s := range f.scrapes {
+		list[i] = yearLabel(s[1])
+		if f.ShowHandle {
+			list[i] = s[0] + " " + list[i]
+		}
+	}
+	return list
+}
+
+// withFailures returns a scraper recording every failed scrape of scrape in f
+func withFailures(scrape scraper, f *failures) scraper {
+	return func(handle, year string) (activity, error) {
+		act, err := scrape(handle, year)
+		if err != nil {
+			f.add(handle, year)
+		}
+		return act, err
+	}
+}
+
+// withPlaceholders wraps scrape to return an empty activity for the years outside of the active years
+// GitHub only lists the years with contributions, the others have no activity overview to scrape
+func withPlaceholders(scrape scraper, active []string) scraper {
+	return func(handle, year string) (activity, error) {
+		if !contains(active, year) {
+			return activity{Handle: handle, Year: year}, nil
+		}
+		return scrape(handle, year)
+	}
+}
+
+// scrapeYears returns all available activity years from a GitHub homepage HTML text
+// the years are returned in chronological order, a list without year links holds no years
+// if strict is set, a year link without a year is an error instead of being skipped
+// if none of the year links hold a year, the error is ErrYearsUnparsed
+func scrapeYears(html []byte, strict bool) ([]string, error) {
+	startList := []byte("<ul class=\"filter-list small\">")
+	endList := []byte("</ul>")
+	startLink := []byte("<a")
+	startYear := []byte("id=\"year-link-")
+	quote := []byte("\"")
+
+	rawYearList, err := extractBetween(html, startList, endList)
+	if err != nil {
+		if interstitial(html) {
+			return nil, ErrBlocked
+		}
+		return nil, fmt.Errorf("extractBetween: %v", err)
+	}
+
+	rawYears := bytes.Split(rawYearList, startLink)
+	rawYears = rawYears[1:] // drop first slice, it only contains <li>
+
+	years := []string{}
+	for _, rawYear := range rawYears {
+		year, err := extractBetween(rawYear, startYear, quote)
+		if err != nil {
+			if strict {
+				return nil, markupDeviation(fmt.Sprintf("year link without a year: %v", err))
+			}
+			logger.Printf("extractBetween: %v", err)
+			continue
+		}
+		years = append(years, string(year))
+	}
+	if len(years) == 0 && len(rawYears) > 0 {
+		return nil, ErrYearsUnparsed
+	}
+
+	sort.Strings(years)
+
+	return years, nil
+}
+
+// coordinates computes the coords forming the path of the activity polygon
+// with the width, minimum delta and total scale of the layout
+func coordinates(activity activity, l layout) coords {
+	const thresh = 0.8
+	w := l.Width
+	h := w * defaultHeight / defaultWidth
+	mid := w / 2
+	factor := w / 10
+	// the chart takes the height of the canvas but for the strip of the handle and year labels
+	graphH := h - labelStrip*factor
+	midY := graphH / 2
+	axisOffset := 2.35
+	axisMargin := axisOffset * factor
+	scale := totalScale(activity.Total, l.MaxTotal)
+	axisLengthX := (mid - axisMargin) * scale
+	axisLengthY := (midY - axisMargin) * scale
+
+	ticks := make([]float64, len(tickPercents))
+	for i, p := range tickPercents {
+		ticks[i] = cappedDelta(float64(p), axisLengthY, thresh, l.MinDelta)
+	}
+
+	return coords{
+		W:           w,
+		H:           h,
+		Mid:         mid,
+		GraphH:      graphH,
+		MidY:        midY,
+		AxisMargin:  axisMargin,
+		Factor:      factor,
+		CodeReviewY: midY - cappedDelta(float64(activity.CodeReviews), axisLengthY, thresh, l.MinDelta),
+		IssuesX:     mid + cappedDelta(float64(activity.Issues), axisLengthX, thresh, l.MinDelta),
+		PrsY:        midY + cappedDelta(float64(activity.Prs), axisLengthY, thresh, l.MinDelta),
+		CommitsX:    mid - cappedDelta(float64(activity.Commits), axisLengthX, thresh, l.MinDelta),
+		Ticks:       ticks,
+	}
+}
+
+// defaultWidth and defaultHeight are the size of the canvas of GitHub's activity overview graph
+const (
+	defaultWidth  = 500.0
+	defaultHeight = 560.0
+)
+
+// labelStrip is the height of the strip of the handle and year labels, in factors of a tenth of the width
+const labelStrip = (defaultHeight - defaultWidth) / (defaultWidth / 10)
+
+// minWidth is the smallest canvas width that keeps the graph legible
+const minWidth = 100
+
+// layout contains the options of the geometry of the activity graph
+type layout struct {
+	Width       float64 // of the canvas, the height keeps the aspect ratio of the default canvas
+	MinDelta    float64 // smallest distance of a non-zero metric from the origin, relative to the axis length
+	SizeByTotal bool    // scale the polygons by their total contributions, MaxTotal is set once all the activities are known
+	MaxTotal    int     // largest total of the activities drawn full size, 0 to not scale them
+}
+
+// totalScale returns the share of the axis length of an activity with total contributions, out of the largest total max
+// the area of the polygon grows with the total, so the axes scale with its square root. A max of 0 does not scale
+func totalScale(total, max int) float64 {
+	if max <= 0 {
+		return 1
+	}
+	return math.Sqrt(float64(total) / float64(max))
+}
+
+// maxTotal returns the largest total contributions of the activities
+func maxTotal(acts []activity) int {
+	max := 0
+	for _, a := range acts {
+		if a.Total > max {
+			max = a.Total
+		}
+	}
+	return max
+}
+
+// cappedDelta will return a delta with magnitude based on n and proportionate to m
+// if the magnitude is greater than thresh, the delta is bumped to m
+// if n is non-zero, the magnitude is at least min
+func cappedDelta(n, m, thresh, min float64) float64 {
+	// wolfram compatile notation: 1-e^{-n/50}
+	// see: https://www.desmos.com/calculator/8pcvpgftdv
+	delta := 1.0 - math.Pow(math.E, n/-50.0)
+	if delta > thresh {
+		delta = 1.0
+	}
+	if n != 0 && delta < min {
+		delta = min
+	}
+	return m * delta
+}
+
+// extractBetween will return the characters in s between the left and right tokens
+func extractBetween(s, left, right []byte) ([]byte, error) {
+	leftIdx := bytes.Index(s, left)
+	if leftIdx == -1 {
+		return nil, patternNotFound(left)
+	}
+
+	leftOffset := leftIdx + len(left)
+	if leftOffset > len(s) {
+		return nil, fmt.Errorf("bytes.Index: left offset larger than s: %s", left)
+	}
+
+	rightIdx := bytes.Index(s[leftOffset:], right)
+	if rightIdx == -1 {
+		return nil, patternNotFound(right)
+	}
+
+	return s[leftOffset : leftOffset+rightIdx], nil
+}
+
+// parseHexColor parses an opaque color in #RRGGBB or #RGB notation
+func parseHexColor(hex string) (color.RGBA, error) {
+	c := color.RGBA{A: 0xff}
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) != 6 {
+		return c, fmt.Errorf("invalid hex color %q, expected #RRGGBB", hex)
+	}
+	rgb, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return c, fmt.Errorf("invalid hex color %q, expected #RRGGBB", hex)
+	}
+	c.R, c.G, c.B = uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)
+	return c, nil
+}
+
+// parseGradient parses the top and bottom hex colors of a gradient in #RRGGBB:#RRGGBB notation
+func parseGradient(spec string) ([]color.Color, error) {
+	stops := strings.Split(spec, ":")
+	if len(stops) != 2 {
+		return nil, fmt.Errorf("invalid gradient %q, expected two colors #RRGGBB:#RRGGBB", spec)
+	}
+	gradient := []color.Color{}
+	for _, stop := range stops {
+		c, err := parseHexColor(stop)
+		if err != nil {
+			return nil, err
+		}
+		gradient = append(gradient, c)
+	}
+	return gradient, nil
+}
+
+// contains reports whether s is one of the options
+func contains(options []string, s string) bool {
+	for _, o := range options {
+		if o == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScrapedInt returns the non-negative integer in raw, ignoring surrounding whitespace and quotes
+func parseScrapedInt(raw []byte) (int, error) {
+	value := bytes.Trim(bytes.TrimSpace(raw), "\"")
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 {
+		return 0, fmt.Errorf("no digits in %q", raw)
+	}
+	for _, b := range value {
+		if b < '0' || b > '9' {
+			return 0, fmt.Errorf("non-digit %q in %q", b, raw)
+		}
+	}
+	return strconv.Atoi(string(value))
+}
+
+// markupDeviation is the error returned by --strict-markup when scraping deviates from the primary path
+func markupDeviation(deviation string) error {
+	return fmt.Errorf("strict markup: %s", deviation)
+}
+
+func patternNotFound(pattern []byte) error {
+	return fmt.Errorf("bytes.Index: could not find %s", pattern)
+}
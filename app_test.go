@@ -0,0 +1,2230 @@
+package gifhub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// testStyle returns the default style with the faces genImg draws the graphs with
+func testStyle(tb testing.TB) style {
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return withFonts(defaultStyle(), font)
+}
+
+func sameImage(a, b image.Image) bool {
+	if a.Bounds() != b.Bounds() {
+		return false
+	}
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// diffBounds returns the smallest rectangle holding the pixels that differ between a and b
+func diffBounds(a, b image.Image) image.Rectangle {
+	var r image.Rectangle
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if a.At(x, y) != b.At(x, y) {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		metric   string
+		raw, pct int
+		want     string
+	}{
+		{"commits", 0, 0, "0%"},
+		{"issues", 120, 12, "12%"},
+		{"prs", 0, 100, "100%"},
+	} {
+		if got := formatValue(tc.metric, tc.raw, tc.pct); got != tc.want {
+			t.Errorf("%s %d %d: expected %q, got %q", tc.metric, tc.raw, tc.pct, tc.want, got)
+		}
+	}
+
+	// a style overrides the default formatting of the values drawn along the axes
+	act := activity{Year: "2020", Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	explicit := s
+	explicit.FormatValue = formatValue
+	if !sameImage(img(g, s), img(g, explicit)) {
+		t.Error("expected formatValue to be the default")
+	}
+	custom := s
+	formatted := map[string]bool{}
+	custom.FormatValue = func(metric string, raw, pct int) string {
+		formatted[metric] = true
+		return fmt.Sprintf("%d‰", pct*10)
+	}
+	if sameImage(img(g, s), img(g, custom)) {
+		t.Error("expected the values drawn with the custom formatter")
+	}
+	if len(formatted) != 4 {
+		t.Errorf("expected the 4 metrics formatted, got %v", formatted)
+	}
+}
+
+func TestLabelPosNoClipping(t *testing.T) {
+	labeled := activity{Handle: "CamiloGarciaLaRotta", Year: "2020", Commits: 40, Issues: 20, Prs: 20, CodeReviews: 20}
+	unlabeled := labeled
+	unlabeled.Handle, unlabeled.Year = "", ""
+	s := testStyle(t)
+	for _, pos := range labelPositions {
+		s.LabelPos = pos
+		a := img(graph{Data: labeled, Coords: coordinates(labeled, layout{Width: defaultWidth})}, s)
+		b := img(graph{Data: unlabeled, Coords: coordinates(unlabeled, layout{Width: defaultWidth})}, s)
+		// the frames only differ by the caption, which must not touch the edges
+		caption, bounds := diffBounds(a, b), a.Bounds()
+		if caption.Empty() {
+			t.Errorf("%s: expected a caption", pos)
+		}
+		if caption.Min.X <= bounds.Min.X || caption.Min.Y <= bounds.Min.Y || caption.Max.X >= bounds.Max.X || caption.Max.Y >= bounds.Max.Y {
+			t.Errorf("%s: expected the caption %v within %v", pos, caption, bounds)
+		}
+	}
+}
+
+func TestFetchMaxBytes(t *testing.T) {
+	const limit = 1024
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/exact" {
+			w.Write(bytes.Repeat([]byte("a"), limit))
+			return
+		}
+		// an endless body, stopped once the client hangs up
+		chunk := bytes.Repeat([]byte("a"), limit)
+		for {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	opts := fetchOptions{MaxBytes: limit}
+
+	body, err := html(srv.URL+"/exact", opts)
+	if err != nil || len(body) != limit {
+		t.Errorf("expected a body of exactly the limit accepted, got %d bytes and %v", len(body), err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := html(srv.URL+"/endless", opts)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "larger than 1024 bytes") {
+			t.Errorf("expected the body rejected past the limit, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the endless body rejected instead of buffered")
+	}
+}
+
+func TestBounce(t *testing.T) {
+	for _, tc := range []struct {
+		frames int
+		want   []int
+	}{
+		{1, []int{0}},
+		{2, []int{0, 1}},
+		{3, []int{0, 1, 2, 1}},
+		{5, []int{0, 1, 2, 3, 4, 3, 2, 1}},
+	} {
+		// every frame is told apart by its single pixel
+		frames := make([]image.Image, tc.frames)
+		for i := range frames {
+			m := image.NewGray(image.Rect(0, 0, 1, 1))
+			m.Pix[0] = uint8(i)
+			frames[i] = m
+		}
+		bounced := bounce(frames)
+		got := make([]int, len(bounced))
+		for i, f := range bounced {
+			got[i] = int(f.(*image.Gray).Pix[0])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%d frames: expected %v, got %v", tc.frames, tc.want, got)
+		}
+	}
+}
+
+// overviewFixture is an activity overview in the 2023 markup, of 10% code reviews, 60% commits, 10% issues and 20% pull requests
+const overviewFixture = `<div class="js-activity-overview-graph-container" data-percentages="{&quot;Code review&quot;:10,&quot;Commits&quot;:60,&quot;Issues&quot;:10,&quot;Pull requests&quot;:20}">`
+
+// challengeFixture is an anti-bot challenge page, served with a 200 status instead of the profile
+const challengeFixture = `<!DOCTYPE html>
+<html lang="en-US">
+<head><title>Just a moment...</title></head>
+<body>
+<div class="main-wrapper" role="main">
+  <h1>github.com</h1>
+  <h2 id="challenge-running">Checking your browser before accessing github.com.</h2>
+  <noscript><div id="challenge-error-title">Enable JavaScript and cookies to continue</div></noscript>
+</div>
+<script src="/cdn-cgi/challenge-platform/h/g/orchestrate/chl_page/v1?ray=8a1b2c3d4e5f6a7b"></script>
+</body>
+</html>`
+
+func TestScrapeBlocked(t *testing.T) {
+	if _, err := scrapeActivity([]byte(challengeFixture), "auto", false); !errors.Is(err, ErrBlocked) {
+		t.Errorf("activity: expected %v, got %v", ErrBlocked, err)
+	}
+	if _, err := scrapeYears([]byte(challengeFixture), false); !errors.Is(err, ErrBlocked) {
+		t.Errorf("years: expected %v, got %v", ErrBlocked, err)
+	}
+
+	// the markers only matter once the activity is missing, a profile may mention them
+	profile := overviewFixture + "<p>Checking your browser extensions</p>"
+	act, err := scrapeActivity([]byte(profile), "auto", false)
+	if err != nil {
+		t.Fatalf("expected the activity of a profile mentioning a marker, got %v", err)
+	}
+	if act.Commits != 60 || act.CodeReviews != 10 {
+		t.Errorf("expected the activity of the fixture, got %+v", act)
+	}
+}
+
+func TestBackoffShared(t *testing.T) {
+	var mu sync.Mutex
+	var limitedAt time.Time
+	limited := make(chan struct{})
+	var served []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if limitedAt.IsZero() {
+			limitedAt = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(limited)
+			return
+		}
+		served = append(served, time.Now())
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+	opts := fetchOptions{MaxBytes: 1 << 20, Backoff: &backoff{}, Retries: 3}
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		if _, err := html(srv.URL, opts); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-limited
+	// the other requests start once the first one is rate limited, and wait along with its retry
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := html(srv.URL, opts); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(served) != 4 {
+		t.Fatalf("expected the retry and the 3 other requests served, got %d", len(served))
+	}
+	for i, at := range served {
+		if wait := at.Sub(limitedAt); wait < 900*time.Millisecond {
+			t.Errorf("request %d: expected it paused for the Retry-After of 1s, served after %v", i, wait)
+		}
+	}
+}
+
+func TestMergeActivities(t *testing.T) {
+	in := make(chan activity, len(sampleActivities))
+	// in the order they are scraped, not by year
+	for i := len(sampleActivities) - 1; i >= 0; i-- {
+		in <- sampleActivities[i]
+	}
+	close(in)
+	var merged []activity
+	for act := range mergeActivities(in) {
+		merged = append(merged, act)
+	}
+	want := activity{Handle: "sample", Year: "2016–2020", Commits: 64, Issues: 10, Prs: 15, CodeReviews: 11, Total: 595}
+	if len(merged) != 1 || !reflect.DeepEqual(merged[0], want) {
+		t.Errorf("expected the single average %+v, got %+v", want, merged)
+	}
+
+	// a missing year does not weigh on the average, and the longest streak is kept
+	in = make(chan activity, 2)
+	in <- activity{Handle: "octocat", Year: "2019", Commits: 50, Issues: 50, Streak: 12}
+	in <- activity{Handle: "octocat", Year: "2021", Commits: 100, Streak: 4}
+	close(in)
+	if got := <-mergeActivities(in); got.Year != "2019–2021" || got.Commits != 75 || got.Issues != 25 || got.Streak != 12 {
+		t.Errorf("expected the average of the 2 years scraped, got %+v", got)
+	}
+
+	// no year scraped, no frame
+	in = make(chan activity)
+	close(in)
+	if _, ok := <-mergeActivities(in); ok {
+		t.Error("expected no activity without years")
+	}
+}
+
+func TestStrictMarkup(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		act, err := scrapeActivity([]byte(overviewFixture), "auto", strict)
+		if err != nil || act.Commits != 60 {
+			t.Errorf("strict %v: expected the primary markup scraped, got %+v %v", strict, act, err)
+		}
+	}
+
+	// the fallback markup version is a deviation
+	if act, err := scrapeActivity([]byte(overview2024Fixture), "auto", false); err != nil || act.Commits != 60 {
+		t.Errorf("expected the fallback markup scraped, got %+v %v", act, err)
+	}
+	if _, err := scrapeActivity([]byte(overview2024Fixture), "auto", true); err == nil || !strings.Contains(err.Error(), "strict markup") {
+		t.Errorf("expected the fallback markup to fail under strict markup, got %v", err)
+	}
+
+	// so is a year link without a year
+	if years, err := scrapeYears([]byte(yearsFixture), false); err != nil || fmt.Sprint(years) != "[2019 2020]" {
+		t.Errorf("expected the years of the links skipping the broken one, got %v %v", years, err)
+	}
+	if _, err := scrapeYears([]byte(yearsFixture), true); err == nil || !strings.Contains(err.Error(), "strict markup") {
+		t.Errorf("expected the broken year link to fail under strict markup, got %v", err)
+	}
+}
+
+// overview2024Fixture is the activity of overviewFixture in the 2024 markup
+const overview2024Fixture = `<div class="js-activity-overview-graph-container" data-percentages='{"Code review":10,"Commits":60,"Issues":10,"Pull requests":20}'>`
+
+// yearsFixture is a list of year links, the last of which lost its year
+const yearsFixture = `<ul class="filter-list small">
+<li><a id="year-link-2019" href="/octocat?tab=overview&amp;from=2019-01-01">2019</a></li>
+<li><a id="year-link-2020" href="/octocat?tab=overview&amp;from=2020-01-01">2020</a></li>
+<li><a href="/octocat?tab=overview">Older</a></li>
+</ul>`
+
+func TestOriginDot(t *testing.T) {
+	dot := color.RGBA{0xff, 0x00, 0xff, 0xff}
+	act := sampleActivities[2]
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	x, y := int(g.Coords.Mid), int(g.Coords.Mid)
+
+	s := testStyle(t)
+	if c := color.RGBAModel.Convert(img(g, s).At(x, y)); c == dot {
+		t.Fatalf("expected the origin without a dot not to be %v", dot)
+	}
+	s.OriginColor = dot
+	if c := color.RGBAModel.Convert(img(g, s).At(x, y)); c != dot {
+		t.Errorf("expected the origin drawn in %v over the polygon and axes, got %v", dot, c)
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	for hex, want := range map[string]color.RGBA{
+		"#ff00ff": {0xff, 0x00, 0xff, 0xff},
+		"#F0A":    {0xff, 0x00, 0xaa, 0xff},
+		"123456":  {0x12, 0x34, 0x56, 0xff},
+	} {
+		if got, err := parseHexColor(hex); err != nil || got != want {
+			t.Errorf("%s: expected %v, got %v %v", hex, want, got, err)
+		}
+	}
+	for _, hex := range []string{"", "#ff00f", "#gg0000"} {
+		if _, err := parseHexColor(hex); err == nil {
+			t.Errorf("%q: expected an invalid color", hex)
+		}
+	}
+}
+
+// sampleFrames renders the frames of the sample activity, with the final one repeated as by --repeat-last
+func sampleFrames(tb testing.TB, repeat int) []image.Image {
+	s := testStyle(tb)
+	frames := []image.Image{}
+	for _, act := range sampleActivities {
+		frames = append(frames, img(graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}, s))
+	}
+	for i := 0; i < repeat; i++ {
+		frames = append(frames, frames[len(frames)-1])
+	}
+	return frames
+}
+
+// decodeGIF decodes the GIF file at path
+func decodeGIF(t *testing.T, path string) *gif.GIF {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	anim, err := gif.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return anim
+}
+
+func TestRepeatLast(t *testing.T) {
+	for _, repeat := range []int{0, 1, 3} {
+		path, err := createGIF(context.Background(), sampleFrames(t, repeat), t.TempDir(), "sample", "gif", gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 40, Palette: palette.Plan9}, encodeGIF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		anim := decodeGIF(t, path)
+		if want := len(sampleActivities) + repeat; len(anim.Image) != want {
+			t.Errorf("repeat %d: expected %d frames, got %d", repeat, want, len(anim.Image))
+		}
+		for i, d := range anim.Delay {
+			if d != 40 {
+				t.Errorf("repeat %d: expected the copies to keep the delay of 40, frame %d got %d", repeat, i, d)
+			}
+		}
+		// the copies are the final year
+		last := anim.Image[len(anim.Image)-1]
+		for i := len(sampleActivities) - 1; i < len(anim.Image)-1; i++ {
+			if !sameImage(anim.Image[i], last) {
+				t.Errorf("repeat %d: expected frame %d to be a copy of the final one", repeat, i)
+			}
+		}
+	}
+}
+
+func TestNoLabels(t *testing.T) {
+	// the text is drawn in colors found nowhere else on the frame
+	s := testStyle(t)
+	s.LabelColor = color.RGBA{0xff, 0x00, 0x00, 0xff}
+	s.ValueColor = color.RGBA{0x00, 0x00, 0xff, 0xff}
+	act := sampleActivities[3]
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+
+	if m := img(g, s); !hasColor(m, s.LabelColor) || !hasColor(m, s.ValueColor) {
+		t.Fatal("expected the text drawn without --no-labels")
+	}
+	bare := s
+	bare.NoLabels = true
+	m := img(g, bare)
+	if hasColor(m, s.LabelColor) || hasColor(m, s.ValueColor) {
+		t.Error("expected no text drawn with --no-labels")
+	}
+	renamed := g
+	renamed.Data.Handle, renamed.Data.Year = "octocat", "2021"
+	if !sameImage(m, img(renamed, bare)) {
+		t.Error("expected the frame not to depend on the handle and year with --no-labels")
+	}
+
+	// any text would be drawn differently in larger faces of the font
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large := func(s style) style {
+		face := truetype.NewFace(font, &truetype.Options{Size: 40})
+		s.LabelFont, s.ValueFont, s.TickFont = face, face, face
+		return s
+	}
+	ticks, rewind := bare, bare
+	ticks.ScaleTicks = true
+	rewind.Rewind = true
+	empty := activity{Handle: "sample", Year: "2021"}
+	days := activity{Handle: "sample", Year: "2020", Days: []day{{Date: "2020-01-01", Level: 2}}}
+	for _, tc := range []struct {
+		name string
+		draw func(graph, style) image.Image
+		g    graph
+		s    style
+	}{
+		{"no activity", img, graph{Data: empty, Coords: coordinates(empty, layout{Width: defaultWidth})}, bare},
+		{"scale ticks", img, g, ticks},
+		{"rewind", img, g, rewind},
+		{"calendar", calendarImg, graph{Data: days}, bare},
+	} {
+		if !sameImage(tc.draw(tc.g, tc.s), tc.draw(tc.g, large(tc.s))) {
+			t.Errorf("%s: expected no text drawn with --no-labels", tc.name)
+		}
+		labeled := tc.s
+		labeled.NoLabels = false
+		if sameImage(tc.draw(tc.g, labeled), tc.draw(tc.g, large(labeled))) {
+			t.Errorf("%s: expected text drawn without --no-labels", tc.name)
+		}
+	}
+}
+
+func TestLegend(t *testing.T) {
+	s := testStyle(t)
+	s.ValueColor = color.RGBA{0x00, 0x00, 0xff, 0xff}
+	s.Legend = true
+	act := sampleActivities[3]
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+
+	// the values are listed in the top right corner only, not along the axes
+	var values image.Rectangle
+	m := img(g, s)
+	want := color.RGBAModel.Convert(s.ValueColor)
+	for y := m.Bounds().Min.Y; y < m.Bounds().Max.Y; y++ {
+		for x := m.Bounds().Min.X; x < m.Bounds().Max.X; x++ {
+			if color.RGBAModel.Convert(m.At(x, y)) == want {
+				values = values.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	corner := image.Rect(int(g.Coords.Mid), 0, int(g.Coords.W), int(2.5*g.Coords.Factor))
+	if values.Empty() || !values.In(corner) {
+		t.Errorf("expected the values listed within %v, got %v", corner, values)
+	}
+}
+
+// hasColor reports whether any pixel of m is c
+func hasColor(m image.Image, c color.Color) bool {
+	want := color.RGBAModel.Convert(c)
+	r := m.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if color.RGBAModel.Convert(m.At(x, y)) == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestBgGradient(t *testing.T) {
+	for _, spec := range []string{"#fff", "#fff:#eee:#ddd", "#fff:#ggg", "white:black", ""} {
+		if _, err := parseGradient(spec); err == nil {
+			t.Errorf("%q: expected an invalid gradient", spec)
+		}
+	}
+	gradient, err := parseGradient("#fff:#eee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.Color{color.RGBA{0xff, 0xff, 0xff, 0xff}, color.RGBA{0xee, 0xee, 0xee, 0xff}}
+	if !reflect.DeepEqual(gradient, want) {
+		t.Errorf("expected %v, got %v", want, gradient)
+	}
+
+	s := testStyle(t)
+	s.BgGradient = []color.Color{color.RGBA{0xff, 0x00, 0x00, 0xff}, color.RGBA{0x00, 0x00, 0xff, 0xff}}
+	act := sampleActivities[0]
+	m := img(graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}, s)
+	b := m.Bounds()
+	top := color.RGBAModel.Convert(m.At(b.Min.X, b.Min.Y)).(color.RGBA)
+	bottom := color.RGBAModel.Convert(m.At(b.Min.X, b.Max.Y-1)).(color.RGBA)
+	if top.R < 0xf0 || top.B > 0x10 || bottom.B < 0xf0 || bottom.R > 0x10 {
+		t.Errorf("expected the background to fade from red at the top to blue at the bottom, got %v and %v", top, bottom)
+	}
+}
+
+func TestSpline(t *testing.T) {
+	act := activity{Commits: 25, Issues: 25, Prs: 25, CodeReviews: 25}
+	c := coordinates(act, layout{Width: defaultWidth})
+	fill := func(path func(coords, *gg.Context)) image.Image {
+		dc := gg.NewContext(int(c.W), int(c.H))
+		dc.SetColor(color.White)
+		dc.Clear()
+		dc.SetColor(color.Black)
+		path(c, dc)
+		dc.Fill()
+		return dc.Image()
+	}
+	straight, smooth := fill(polygon), fill(spline)
+	black := color.RGBAModel.Convert(color.Black)
+	filled := func(m image.Image, x, y float64) bool {
+		return color.RGBAModel.Convert(m.At(int(x), int(y))) == black
+	}
+
+	// the middle of the edge between the code review and issues vertices, pushed away from the origin
+	mx, my := (c.Mid+c.IssuesX)/2, (c.CodeReviewY+c.Mid)/2
+	dx, dy := mx-c.Mid, my-c.Mid
+	n := math.Hypot(dx, dy)
+	ox, oy := mx+4*dx/n, my+4*dy/n
+	if filled(straight, ox, oy) {
+		t.Error("expected the straight edge not to reach past the middle of the edge")
+	}
+	if !filled(smooth, ox, oy) {
+		t.Error("expected the spline to bulge past the middle of the straight edge")
+	}
+	// both go through the vertices, a pixel inside of them
+	for name, m := range map[string]image.Image{"polygon": straight, "spline": smooth} {
+		if !filled(m, c.Mid, c.CodeReviewY+2) || !filled(m, c.IssuesX-2, c.Mid) {
+			t.Errorf("%s: expected the path through the vertices", name)
+		}
+	}
+}
+
+func TestFPS(t *testing.T) {
+	for _, tc := range []struct {
+		fps   float64
+		delay int
+	}{
+		{1, 100},
+		{10, 10},
+		{25, 4},
+		{3, 33},   // approximated
+		{1000, 1}, // the shortest delay
+	} {
+		delay, err := fpsDelay(tc.fps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if delay != tc.delay {
+			t.Errorf("%g fps: expected the delay %d, got %d", tc.fps, tc.delay, delay)
+		}
+	}
+	for _, fps := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := fpsDelay(fps); err == nil {
+			t.Errorf("expected %g fps to be invalid", fps)
+		}
+	}
+
+}
+
+func TestAutocrop(t *testing.T) {
+	frame := func(content image.Rectangle) image.Image {
+		m := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		draw.Draw(m, m.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(m, content, image.Black, image.Point{}, draw.Src)
+		return m
+	}
+	frames := []image.Image{frame(image.Rect(20, 30, 40, 50)), frame(image.Rect(50, 40, 60, 70))}
+	cropped := autocrop(frames, 5)
+	// the union of 20,30-60,70 and the margin
+	want := image.Rect(0, 0, 50, 50)
+	for i, f := range cropped {
+		if f.Bounds() != want {
+			t.Errorf("frame %d: expected the bounds %v, got %v", i, want, f.Bounds())
+		}
+	}
+	if !hasColor(cropped[0], color.Black) || !hasColor(cropped[1], color.Black) {
+		t.Error("expected the content to be kept")
+	}
+	if got := autocrop(frames, 50)[0].Bounds(); got != frames[0].Bounds() {
+		t.Errorf("expected the margin to stay within the frame, got %v", got)
+	}
+
+	// the sample frames are cropped to a single size, smaller than the canvas
+	sample := sampleFrames(t, 0)
+	canvas := sample[0].Bounds()
+	cropped = autocrop(sample, autocropMargin)
+	for i, f := range cropped {
+		if f.Bounds() != cropped[0].Bounds() {
+			t.Errorf("frame %d: expected the bounds %v of the first frame, got %v", i, cropped[0].Bounds(), f.Bounds())
+		}
+	}
+	if b := cropped[0].Bounds(); b.Dx() >= canvas.Dx() && b.Dy() >= canvas.Dy() {
+		t.Errorf("expected the frames smaller than %v, got %v", canvas, b)
+	}
+}
+
+func TestMarkupVersion(t *testing.T) {
+	fixtures := map[string]string{"2023": overviewFixture, "2024": overview2024Fixture}
+	for version, fixture := range fixtures {
+		for _, v := range []string{version, "auto"} {
+			act, err := scrapeActivity([]byte(fixture), v, false)
+			if err != nil || act.Commits != 60 || act.Issues != 10 {
+				t.Errorf("%s markup as %s: expected the activity scraped, got %+v %v", version, v, act, err)
+			}
+		}
+		// the other named version does not match
+		for other := range fixtures {
+			if other == version {
+				continue
+			}
+			if _, err := scrapeActivity([]byte(fixture), other, false); err == nil {
+				t.Errorf("%s markup as %s: expected an error", version, other)
+			}
+		}
+	}
+	if _, err := scrapeActivity([]byte(overviewFixture), "2022", false); err == nil || !strings.Contains(err.Error(), "unknown markup version") {
+		t.Errorf("expected an unknown markup version, got %v", err)
+	}
+
+	if names := markupVersionNames(); !contains(names, "auto") || !contains(names, "2023") || !contains(names, "2024") {
+		t.Errorf("expected auto and both markup versions named, got %v", names)
+	}
+}
+
+func TestScrapeActivityDeterministic(t *testing.T) {
+	// the keys in an unusual order and padded
+	reordered := `<div data-percentages='{"Pull requests": 20, "Issues":10 ,"Commits":60,"Code review":10}'>`
+	want := activity{Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}
+	for _, fixture := range []string{overviewFixture, overview2024Fixture, reordered} {
+		for i := 0; i < 20; i++ {
+			act, err := scrapeActivity([]byte(fixture), "auto", false)
+			if err != nil || !reflect.DeepEqual(act, want) {
+				t.Fatalf("run %d of %s: expected %+v, got %+v %v", i, fixture, want, act, err)
+			}
+		}
+	}
+
+	// with several keys missing, the error names the first one in the fixed order
+	missing := `<div data-percentages='{"Pull requests":20,"Issues":10}'>`
+	for i := 0; i < 20; i++ {
+		_, err := scrapeActivity([]byte(missing), "auto", false)
+		if err == nil || !strings.Contains(err.Error(), `did not find "Code review"`) {
+			t.Fatalf("run %d: expected the missing code review, got %v", i, err)
+		}
+	}
+}
+
+func TestLabelTemplate(t *testing.T) {
+	act := activity{Handle: "octocat", Year: "2020", Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10, Streak: 7}
+	for _, tc := range []struct {
+		flag, label string
+	}{
+		{defaultLabelTemplate, "octocat\n2020"},
+		{"{handle} — {year} ({commits}% commits)", "octocat — 2020 (60% commits)"},
+		{`{prs} PRs\n{codeReviews} reviews, {issues} issues`, "20 PRs\n10 reviews, 10 issues"},
+		{"{streak} day streak", "7 day streak"},
+		{"no placeholders", "no placeholders"},
+	} {
+		tmpl, err := parseLabelTemplate(tc.flag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := expandLabel(tmpl, act); got != tc.label {
+			t.Errorf("%q: expected %q, got %q", tc.flag, tc.label, got)
+		}
+	}
+
+	_, err := parseLabelTemplate("{handle} {stars}")
+	if err == nil || !strings.Contains(err.Error(), "unknown placeholder {stars}") || !strings.Contains(err.Error(), "{commits}") {
+		t.Errorf("expected the unknown placeholder listing the valid ones, got %v", err)
+	}
+}
+
+func TestYearsFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	for _, tc := range []struct {
+		content string
+		years   []string
+	}{
+		{"2015,2017-2019\n2021\n", []string{"2015", "2017", "2018", "2019", "2021"}},
+		{"2016-2017\r\n\r\n2020, 2022\n", []string{"2016", "2017", "2020", "2022"}},
+		{"2021-03:2021-09\n2022", []string{"2021-03:2021-09", "2022"}},
+	} {
+		path := write("years.txt", tc.content)
+		years, err := parseYearFlag("@"+path, "octocat", fetchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(years, tc.years) {
+			t.Errorf("%q: expected %v, got %v", tc.content, tc.years, years)
+		}
+	}
+
+	for _, tc := range []struct {
+		path, err string
+	}{
+		{filepath.Join(dir, "missing.txt"), "read years"},
+		{write("empty.txt", "\n ,\n"), "no years"},
+		{write("words.txt", "2019\nlast year\n"), `invalid year "last"`},
+		{write("reversed.txt", "2019-2017"), "is after"},
+	} {
+		if _, err := parseYearFlag("@"+tc.path, "octocat", fetchOptions{}); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.path, tc.err, err)
+		}
+	}
+}
+
+// roundTripper stubs the requests of the default HTTP client with a handler
+type roundTripper struct{ http.Handler }
+
+func (rt roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	res := w.Result()
+	res.Request = r
+	return res, nil
+}
+
+// stubGitHub answers the requests to GitHub with h until the end of the test
+func stubGitHub(t *testing.T, h http.HandlerFunc) {
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = roundTripper{h}
+	t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+}
+
+func TestJoinYear(t *testing.T) {
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/octocat":
+			fmt.Fprint(w, `{"login":"octocat","created_at":"2011-01-25T18:44:36Z"}`)
+		case "/users/ghost":
+			fmt.Fprint(w, `{"login":"ghost"}`)
+		default:
+			fmt.Fprint(w, `not json`)
+		}
+	})
+	opts := fetchOptions{MaxBytes: 1 << 20}
+	if year, err := joinYear("octocat", opts); err != nil || year != 2011 {
+		t.Errorf("expected the year the account was created, got %d %v", year, err)
+	}
+	for _, handle := range []string{"ghost", "broken"} {
+		if _, err := joinYear(handle, opts); err == nil || !strings.Contains(err.Error(), "join year") {
+			t.Errorf("%s: expected no join year, got %v", handle, err)
+		}
+	}
+}
+
+func TestWithPlaceholders(t *testing.T) {
+	var scraped []string
+	scrape := withPlaceholders(func(handle, year string) (activity, error) {
+		scraped = append(scraped, year)
+		return activity{Handle: handle, Year: year, Commits: 60}, nil
+	}, []string{"2020"})
+	for _, year := range []string{"2018", "2019", "2020"} {
+		act, err := scrape("octocat", year)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case act.Handle != "octocat" || act.Year != year:
+			t.Errorf("expected the activity of octocat in %s, got %+v", year, act)
+		case year == "2020" && act.Commits != 60:
+			t.Errorf("expected the activity of %s scraped, got %+v", year, act)
+		case year != "2020" && act.Commits != 0:
+			t.Errorf("expected %s without activity, got %+v", year, act)
+		}
+	}
+	// GitHub only lists the years with contributions, the others are not requested
+	if fmt.Sprint(scraped) != "[2020]" {
+		t.Errorf("expected only the active year scraped, got %v", scraped)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	for _, tc := range []struct {
+		keepAlive bool
+		conns     int64
+	}{
+		{true, 1},
+		{false, 3},
+	} {
+		var conns int64
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, overviewFixture)
+		}))
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt64(&conns, 1)
+			}
+		}
+		srv.Start()
+
+		opts := fetchOptions{MaxBytes: 1 << 20, Client: newClient(tc.keepAlive, 0)}
+		for _, year := range []string{"2019", "2020", "2021"} {
+			if _, err := html(srv.URL+"/octocat?tab=overview&from="+year+"-01-01", opts); err != nil {
+				t.Fatal(err)
+			}
+		}
+		srv.Close()
+		if got := atomic.LoadInt64(&conns); got != tc.conns {
+			t.Errorf("keep-alive %v: expected %d connections for 3 requests, got %d", tc.keepAlive, tc.conns, got)
+		}
+	}
+}
+
+func TestHighlightMax(t *testing.T) {
+	for _, tc := range []struct {
+		act  activity
+		want []string
+	}{
+		{activity{Commits: 10, Issues: 50, Prs: 20, CodeReviews: 20}, []string{"issues"}},
+		{activity{Commits: 70, Issues: 10, Prs: 10, CodeReviews: 10}, []string{"commits"}},
+		{activity{Commits: 40, Issues: 10, Prs: 40, CodeReviews: 10}, []string{"commits", "prs"}},
+		{activity{}, nil},
+	} {
+		got := largestMetrics(tc.act)
+		if len(got) != len(tc.want) {
+			t.Errorf("%+v: expected %v, got %v", tc.act, tc.want, got)
+		}
+		for _, name := range tc.want {
+			if !got[name] {
+				t.Errorf("%+v: expected %s highlighted, got %v", tc.act, name, got)
+			}
+		}
+	}
+
+	act := activity{Handle: "octocat", Year: "2020", Commits: 10, Issues: 50, Prs: 20, CodeReviews: 20}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	s.HighlightColor = highlightColor
+	m := img(g, s)
+
+	// the ends of the axes, past the vertices of the polygon
+	y, margin := int(g.Coords.Mid), int(g.Coords.AxisMargin)
+	issues := color.RGBAModel.Convert(m.At(int(g.Coords.W)-margin-2, y))
+	commits := color.RGBAModel.Convert(m.At(margin+2, y))
+	if issues != color.RGBAModel.Convert(highlightColor) {
+		t.Errorf("expected the issues axis in the highlight color, got %v", issues)
+	}
+	if commits != color.RGBAModel.Convert(s.AxisColor) {
+		t.Errorf("expected the commits axis in the axis color %v, got %v", s.AxisColor, commits)
+	}
+}
+
+func TestEncodeGIFZeroDelay(t *testing.T) {
+	frames := sampleFrames(t, 1)
+	dir := t.TempDir()
+
+	// a single frame is a valid static GIF
+	path, err := createGIF(context.Background(), frames[:1], dir, "static", "gif", gifOptions{Palette: palette.Plan9}, encodeGIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim := decodeGIF(t, path)
+	if len(anim.Image) != 1 || anim.Delay[0] != 0 {
+		t.Errorf("expected a static frame, got %d frames with the delays %v", len(anim.Image), anim.Delay)
+	}
+
+	var buf bytes.Buffer
+	err = encodeGIF(context.Background(), &buf, frames, gifOptions{Palette: palette.Plan9})
+	if err == nil || !strings.Contains(err.Error(), "only valid for a single frame") {
+		t.Errorf("expected several frames without a delay to fail, got %v", err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("expected nothing written, got %d bytes", buf.Len())
+	}
+}
+
+func TestMinDelta(t *testing.T) {
+	act := activity{Handle: "octocat", Year: "2020", Commits: 0, Issues: 1, Prs: 49, CodeReviews: 50}
+	plain := coordinates(act, layout{Width: defaultWidth})
+	floored := coordinates(act, layout{Width: defaultWidth, MinDelta: 0.2})
+	axisLength := plain.Mid - plain.AxisMargin
+
+	if d := plain.IssuesX - plain.Mid; d > 0.05*axisLength {
+		t.Fatalf("expected a 1%% metric next to the origin by default, got %g of %g", d, axisLength)
+	}
+	if d := floored.IssuesX - floored.Mid; d < 0.2*axisLength-0.5 {
+		t.Errorf("expected a 1%% metric at least a fifth of the axis away from the origin, got %g of %g", d, axisLength)
+	}
+	if floored.CommitsX != floored.Mid {
+		t.Errorf("expected a zero metric at the origin, got %g", floored.CommitsX)
+	}
+	if floored.PrsY != plain.PrsY || floored.CodeReviewY != plain.CodeReviewY {
+		t.Error("expected the metrics past the minimum unchanged")
+	}
+
+	// the white center of the marker is drawn away from the origin
+	s := testStyle(t)
+	x, y := int(floored.IssuesX), int(floored.Mid)
+	white := color.RGBAModel.Convert(color.White)
+	if c := color.RGBAModel.Convert(img(graph{Data: act, Coords: plain}, s).At(x, y)); c == white {
+		t.Fatalf("expected no marker at %d,%d by default", x, y)
+	}
+	if c := color.RGBAModel.Convert(img(graph{Data: act, Coords: floored}, s).At(x, y)); c != white {
+		t.Errorf("expected the marker at %d,%d with a minimum delta, got %v", x, y, c)
+	}
+}
+
+func TestParseScrapedInt(t *testing.T) {
+	for raw, want := range map[string]int{`20`: 20, ` 20 `: 20, `"20"`: 20, `" 7 "`: 7, "\n\t0\n": 0} {
+		if got, err := parseScrapedInt([]byte(raw)); err != nil || got != want {
+			t.Errorf("%q: expected %d, got %d %v", raw, want, got, err)
+		}
+	}
+	for _, raw := range []string{``, `""`, `-5`, `12%`, `1.5`, `"n/a"`} {
+		if _, err := parseScrapedInt([]byte(raw)); err == nil {
+			t.Errorf("%q: expected an error", raw)
+		}
+	}
+}
+
+func TestScrapeActivityPadded(t *testing.T) {
+	// the counts are padded with whitespace, and quoted as strings
+	padded := `<div data-percentages='{"Code review": 10 ,"Commits":"  60","Issues":" 10 ","Pull requests":
+	20}'>`
+	act, err := scrapeActivity([]byte(padded), "auto", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (activity{Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}); !reflect.DeepEqual(act, want) {
+		t.Errorf("expected %+v, got %+v", want, act)
+	}
+
+	// the error of an unparseable count names its key and raw value
+	unparseable := `<div data-percentages='{"Code review":10,"Commits":"6O","Issues":10,"Pull requests":20}'>`
+	_, err = scrapeActivity([]byte(unparseable), "auto", false)
+	if err == nil || !strings.Contains(err.Error(), "Commits") || !strings.Contains(err.Error(), `6O`) {
+		t.Errorf("expected the raw value of the commits in the error, got %v", err)
+	}
+}
+
+func TestAxisExtent(t *testing.T) {
+	act := activity{Handle: "octocat", Year: "2020", Commits: 10, Issues: 30, Prs: 30, CodeReviews: 30}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	y, margin := int(g.Coords.Mid), int(g.Coords.AxisMargin)
+	// on the commits half axis: past the margin, within the margin, and between the margin and the vertex
+	edge, inMargin, pastVertex := 1, margin+2, (margin+int(g.Coords.CommitsX))/2
+
+	for _, tc := range []struct {
+		extent string
+		drawn  map[int]bool
+	}{
+		{"full", map[int]bool{edge: false, inMargin: true, pastVertex: true}},
+		{"edge", map[int]bool{edge: true, inMargin: true, pastVertex: true}},
+		{"to-vertex", map[int]bool{edge: false, inMargin: false, pastVertex: false}},
+	} {
+		s := testStyle(t)
+		s.AxisExtent = tc.extent
+		m := img(g, s)
+		axis := color.RGBAModel.Convert(s.AxisColor)
+		for x, drawn := range tc.drawn {
+			if got := color.RGBAModel.Convert(m.At(x, y)) == axis; got != drawn {
+				t.Errorf("%s: expected the axis drawn at %d,%d %v, got %v", tc.extent, x, y, drawn, got)
+			}
+		}
+		// the axis reaches the marker of the vertex
+		if c := color.RGBAModel.Convert(m.At(int(g.Coords.CommitsX)+int(s.MarkerRadius)+2, y)); c != axis {
+			t.Errorf("%s: expected the axis drawn past the marker, got %v", tc.extent, c)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	for _, token := range []string{"ghp_secret", ""} {
+		if _, err := html(srv.URL, fetchOptions{MaxBytes: 1 << 20, Token: token}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(auth) != 2 || auth[0] != "token ghp_secret" || auth[1] != "" {
+		t.Errorf("expected the token only on the authenticated request, got %q", auth)
+	}
+}
+
+func TestValidateGIF(t *testing.T) {
+	frames := sampleFrames(t, 1)
+	dir := t.TempDir()
+	valid, err := createGIF(context.Background(), frames, dir, "sample", "gif", gifOptions{FirstDelay: 100, Delay: 100, FinalDelay: 100, Palette: palette.Plan9}, encodeGIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if err := validateGIF(valid, frames); err != nil {
+		t.Errorf("expected the GIF to be valid, got %v", err)
+	}
+	if err := validateGIF(valid, frames[1:]); err == nil || !strings.Contains(err.Error(), "frames") {
+		t.Errorf("expected a frame count mismatch, got %v", err)
+	}
+	small := []image.Image{}
+	for range frames {
+		small = append(small, image.NewRGBA(image.Rect(0, 0, 10, 10)))
+	}
+	if err := validateGIF(valid, small); err == nil || !strings.Contains(err.Error(), "10x10") {
+		t.Errorf("expected a size mismatch, got %v", err)
+	}
+
+	// a file cut short, as by a full disk, and overwritten bytes
+	truncated := write("truncated.gif", data[:len(data)/2])
+	corrupted := append([]byte{}, data...)
+	copy(corrupted[:6], "PNG89a")
+	for _, path := range []string{truncated, write("corrupted.gif", corrupted)} {
+		if err := validateGIF(path, frames); err == nil {
+			t.Errorf("%s: expected the validation to fail", filepath.Base(path))
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	act := activity{Handle: "sample", Year: "2020", Commits: 50, Issues: 20, Prs: 20, CodeReviews: 10}
+	small := coordinates(act, layout{Width: defaultWidth})
+	large := coordinates(act, layout{Width: 2 * defaultWidth})
+	if large.W != 2*small.W || large.H != 2*small.H {
+		t.Errorf("expected the canvas to double, got %vx%v from %vx%v", large.W, large.H, small.W, small.H)
+	}
+	if large.CommitsX != 2*small.CommitsX || large.CodeReviewY != 2*small.CodeReviewY {
+		t.Errorf("expected the markers to scale with the canvas, got %+v from %+v", large, small)
+	}
+
+	s := scaleStyle(testStyle(t), 2)
+	if s.Scale != 2 || s.MarkerRadius != 2*defaultStyle().MarkerRadius {
+		t.Errorf("expected the style to double, got scale %v and radius %v", s.Scale, s.MarkerRadius)
+	}
+	m := img(graph{Data: act, Coords: large}, s)
+	if b := m.Bounds(); b.Dx() != 1000 || b.Dy() != 1120 {
+		t.Errorf("expected a 1000x1120 image, got %v", b)
+	}
+}
+func TestScaleTicks(t *testing.T) {
+	c := coordinates(activity{}, layout{Width: defaultWidth})
+	axisLength := c.Mid - c.AxisMargin
+	// the inverse of the curve of cappedDelta, 1-e^{-p/50}, bumped to the axis end past 0.8
+	for i, want := range []float64{0.3935, 0.6321, 0.7769, 1} {
+		if got := c.Ticks[i] / axisLength; math.Abs(got-want) > 1e-4 {
+			t.Errorf("%d%%: expected the tick at %.4f of the axis, got %.4f", tickPercents[i], want, got)
+		}
+	}
+	// a metric of the percentage of a tick reaches it
+	for i, p := range tickPercents {
+		v := coordinates(activity{CodeReviews: p}, layout{Width: defaultWidth})
+		if got := v.Mid - v.CodeReviewY; math.Abs(got-c.Ticks[i]) > 1e-9 {
+			t.Errorf("%d%%: expected the vertex at the tick %g, got %g", p, c.Ticks[i], got)
+		}
+	}
+
+	act := activity{Handle: "octocat", Year: "2020", Commits: 40, Issues: 40, Prs: 10, CodeReviews: 10}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	plain := img(g, s)
+	s.ScaleTicks = true
+	ticked := img(g, s)
+	// the mark of every tick crosses the code review axis, beside it
+	factor := g.Coords.W / 10
+	for i, d := range g.Coords.Ticks {
+		x, y := int(g.Coords.Mid+0.08*factor), int(g.Coords.Mid-d)
+		if plain.At(x, y) == ticked.At(x, y) {
+			t.Errorf("%d%%: expected the tick mark drawn at %d,%d", tickPercents[i], x, y)
+		}
+	}
+}
+
+func TestCoordinatesVerticalGeometry(t *testing.T) {
+	for _, width := range []float64{minWidth, defaultWidth, 1000} {
+		c := coordinates(activity{Commits: 40, Issues: 20, Prs: 20, CodeReviews: 20}, layout{Width: width})
+		if c.GraphH != c.H-labelStrip*c.Factor {
+			t.Errorf("width %v: expected the chart to leave the label strip, got height %v of %v", width, c.GraphH, c.H)
+		}
+		if c.MidY != c.GraphH/2 {
+			t.Errorf("width %v: expected the vertical center at %v, got %v", width, c.GraphH/2, c.MidY)
+		}
+		// code reviews and pull requests are both 20%, on either side of the vertical center
+		if up, down := c.MidY-c.CodeReviewY, c.PrsY-c.MidY; math.Abs(up-down) > 1e-9 || up <= 0 {
+			t.Errorf("width %v: expected the vertical vertices %v and %v symmetric about %v", width, c.CodeReviewY, c.PrsY, c.MidY)
+		}
+	}
+}
+
+func TestGrid(t *testing.T) {
+	act := activity{Handle: "octocat", Year: "2020", Commits: 1, Issues: 1, Prs: 1, CodeReviews: 1}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	plain := img(g, s)
+	s.Grid = true
+	grid := img(g, s)
+	// every ring crosses the middle of the edge between two axes, away from the small polygon
+	mid, midY := g.Coords.Mid, g.Coords.MidY
+	for i, d := range g.Coords.Ticks {
+		for _, p := range [][2]float64{{mid + d/2, midY - d/2}, {mid - d/2, midY + d/2}} {
+			x, y := int(p[0]), int(p[1])
+			if plain.At(x, y) == grid.At(x, y) {
+				t.Errorf("%d%%: expected the ring drawn at %d,%d", tickPercents[i], x, y)
+			}
+		}
+	}
+}
+
+func TestStyleColors(t *testing.T) {
+	act := activity{Handle: "octocat", Year: "2020", Commits: 40, Issues: 40, Prs: 10, CodeReviews: 10}
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	colors := map[string]*color.Color{
+		"#112233": &s.AxisColor,
+		"#445566": &s.PolyColor,
+		"#778899": &s.LabelColor,
+		"#aabbcc": &s.ValueColor,
+	}
+	for hex, field := range colors {
+		c, err := parseHexColor(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*field = c
+	}
+	m := img(g, s)
+	for hex, field := range colors {
+		if !hasColor(m, *field) {
+			t.Errorf("expected %s drawn", hex)
+		}
+	}
+}
+
+func TestCanonicalHandle(t *testing.T) {
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ghost":
+			fmt.Fprint(w, `<meta property="profile:username" content="someone-else">`)
+		case "/blocked":
+			fmt.Fprint(w, challengeFixture)
+		default:
+			fmt.Fprint(w, `<head><meta property="profile:username" content="OctoCat"></head>`)
+		}
+	})
+	opts := fetchOptions{MaxBytes: 1 << 20}
+
+	if handle, err := canonicalHandle("octocat", opts); err != nil || handle != "OctoCat" {
+		t.Errorf("expected the casing of the profile, got %q %v", handle, err)
+	}
+	if _, err := canonicalHandle("ghost", opts); err == nil || !strings.Contains(err.Error(), "profile of ghost is someone-else") {
+		t.Errorf("expected a profile of another user to fail, got %v", err)
+	}
+	if _, err := canonicalHandle("blocked", opts); err != ErrBlocked {
+		t.Errorf("expected a challenge page to be reported as blocked, got %v", err)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	var frames []activityImage
+	for _, year := range []string{"2019", "2021", "2020"} {
+		frames = append(frames, activityImage{Img: image.NewRGBA(image.Rect(0, 0, 1, 1)), Year: year})
+	}
+	for reverse, want := range map[bool][]string{false: {"2019", "2020", "2021"}, true: {"2021", "2020", "2019"}} {
+		imgs := sortImgs(append([]activityImage{}, frames...), reverse)
+		for i, year := range want {
+			var frame image.Image
+			for _, f := range frames {
+				if f.Year == year {
+					frame = f.Img
+				}
+			}
+			if imgs[i] != frame {
+				t.Errorf("reverse %v: expected %s at %d", reverse, year, i)
+			}
+		}
+	}
+}
+
+func TestRewind(t *testing.T) {
+	// the cue is drawn in the top left corner, inside the first triangle
+	act := sampleActivities[4]
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	factor := g.Coords.W / 10
+	x, y := int(0.3*factor+0.24*factor*0.75), int(0.5*factor)
+	s := testStyle(t)
+	bg := color.RGBAModel.Convert(img(g, s).At(x, y))
+	s.Rewind = true
+	if c := color.RGBAModel.Convert(img(g, s).At(x, y)); c == bg {
+		t.Errorf("expected the rewind cue drawn at %d,%d, got the background %v", x, y, c)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	for _, loop := range []int{-1, 0, 3} {
+		path, err := createGIF(context.Background(), sampleFrames(t, 0), t.TempDir(), "sample", "gif", gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 40, Loop: loop, Palette: palette.Plan9}, encodeGIF)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := decodeGIF(t, path).LoopCount; got != loop {
+			t.Errorf("expected the loop count %d, got %d", loop, got)
+		}
+	}
+}
+
+func TestFinalDelay(t *testing.T) {
+	path, err := createGIF(context.Background(), sampleFrames(t, 0), t.TempDir(), "sample", "gif", gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 300, Palette: palette.Plan9}, encodeGIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delays := decodeGIF(t, path).Delay
+	for i, d := range delays[:len(delays)-1] {
+		if d != 40 {
+			t.Errorf("frame %d: expected the delay 40, got %d", i, d)
+		}
+	}
+	if last := delays[len(delays)-1]; last != 300 {
+		t.Errorf("expected the final frame held for 300, got %d", last)
+	}
+}
+
+func TestOutputPath(t *testing.T) {
+	for _, tc := range []struct {
+		flag, dir, name string
+		isFile          bool
+	}{
+		{"./out", "./out", "octocat", false},
+		{"out/myfile.gif", "out", "myfile", true},
+		{"myfile.GIF", ".", "myfile", true},
+		{"out/.gif", "out/.gif", "octocat", false}, // a hidden directory
+		{"out/gifs", "out/gifs", "octocat", false},
+	} {
+		dir, name, isFile := outputPath(tc.flag, "octocat", "gif")
+		if dir != tc.dir || name != tc.name || isFile != tc.isFile {
+			t.Errorf("%s: expected %s %s %v, got %s %s %v", tc.flag, tc.dir, tc.name, tc.isFile, dir, name, isFile)
+		}
+	}
+
+	root := t.TempDir()
+	if err := checkWritable(root); err != nil {
+		t.Errorf("expected the directory to be writable, got %v", err)
+	}
+	file := filepath.Join(root, "myfile.gif")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(file); err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Errorf("expected a file to fail, got %v", err)
+	}
+	if err := checkWritable(filepath.Join(root, "missing")); err == nil {
+		t.Error("expected a missing directory to fail")
+	}
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected the probe file removed, got %d files", len(files))
+	}
+}
+
+func TestRetries(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests%2 == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	if body, err := html(srv.URL, fetchOptions{MaxBytes: 1 << 20, Backoff: &backoff{}, Retries: 1}); err != nil || string(body) != "ok" {
+		t.Errorf("expected the 5xx status retried, got %q %v", body, err)
+	}
+	if _, err := html(srv.URL, fetchOptions{MaxBytes: 1 << 20, Backoff: &backoff{}}); err == nil {
+		t.Error("expected the 5xx status to fail without retries")
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	for err, want := range map[error]bool{
+		io.ErrUnexpectedEOF:                      true,
+		&net.OpError{Op: "dial", Err: io.EOF}:    true,
+		fmt.Errorf("get: %w", io.EOF):            true,
+		errors.New("stopped after 10 redirects"): false,
+	} {
+		if got := transient(err); got != want {
+			t.Errorf("%v: expected transient %v, got %v", err, want, got)
+		}
+	}
+}
+func TestSerialConcurrency(t *testing.T) {
+	years := []string{"2021", "2016", "2019", "2017", "2020", "2018"}
+	var mu sync.Mutex
+	var fetched []string
+	// the earlier a year is requested the longer it takes, so parallel workers would finish them out of order
+	scrape := scraper(func(handle, year string) (activity, error) {
+		mu.Lock()
+		fetched = append(fetched, year)
+		wait := time.Duration(len(years)-len(fetched)) * time.Millisecond
+		mu.Unlock()
+		time.Sleep(wait)
+		return activity{Handle: handle, Year: year}, nil
+	})
+	got := []string{}
+	for act := range genActivities("octocat", genYears(years, len(years)), len(years), 1, scrape) {
+		got = append(got, act.Year)
+	}
+	if !reflect.DeepEqual(fetched, years) || !reflect.DeepEqual(got, years) {
+		t.Errorf("expected the years scraped and passed in the order %v, got %v and %v", years, fetched, got)
+	}
+}
+
+func TestConcurrencyLimit(t *testing.T) {
+	years := []string{"2016", "2017", "2018", "2019", "2020", "2021"}
+	var inFlight, peak int32
+	scrape := scraper(func(handle, year string) (activity, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return activity{Handle: handle, Year: year}, nil
+	})
+	n := 0
+	for range genActivities("octocat", genYears(years, len(years)), len(years), 2, scrape) {
+		n++
+	}
+	if n != len(years) {
+		t.Errorf("expected %d activities, got %d", len(years), n)
+	}
+	if peak > 2 {
+		t.Errorf("expected at most 2 years scraped at once, got %d", peak)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	opts := fetchOptions{MaxBytes: 1 << 20, Client: newClient(true, 10*time.Millisecond)}
+	if _, err := html(srv.URL, opts); err == nil || !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+}
+
+// countingTransport counts the requests sent through it before passing them to next
+type countingTransport struct {
+	next     http.RoundTripper
+	mu       sync.Mutex
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.requests++
+	c.mu.Unlock()
+	return c.next.RoundTrip(r)
+}
+
+func TestInjectedClient(t *testing.T) {
+	transport := &countingTransport{next: roundTripper{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); !strings.HasPrefix(ua, "gifhub ") {
+			t.Errorf("%s: expected the gifhub User-Agent, got %q", r.URL, ua)
+		}
+		fmt.Fprint(w, overviewFixture)
+	})}}
+	opts := fetchOptions{MaxBytes: 1 << 20, MarkupVersion: "auto", Client: &http.Client{Transport: transport}}
+	for _, year := range []string{"2019", "2020"} {
+		if _, err := parseActivity("octocat", year, opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if transport.requests != 2 {
+		t.Errorf("expected every request sent through the injected client, got %d of 2", transport.requests)
+	}
+}
+
+func TestScrapeYearsUnparsed(t *testing.T) {
+	defer silence()()
+
+	unparsed := `<ul class="filter-list small"><li><a href="/octocat?tab=overview&from=2020-01-01" class="filter-item">2020</a></li><li><a class="filter-item">2019</a></li></ul>`
+	if _, err := scrapeYears([]byte(unparsed), false); !errors.Is(err, ErrYearsUnparsed) {
+		t.Errorf("expected %v, got %v", ErrYearsUnparsed, err)
+	}
+
+	empty := `<ul class="filter-list small"></ul>`
+	if years, err := scrapeYears([]byte(empty), false); err != nil || len(years) != 0 {
+		t.Errorf("expected no years from an empty list, got %v %v", years, err)
+	}
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, empty)
+	})
+	_, err := parseYearFlag("all", "octocat", fetchOptions{MaxBytes: 1 << 20})
+	if err == nil || errors.Is(err, ErrYearsUnparsed) || !strings.Contains(err.Error(), "no years available, octocat has no activity") {
+		t.Errorf("expected a profile without years to be reported as such, got %v", err)
+	}
+}
+
+func TestPad(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	const padding = 20
+	frames := sampleFrames(t, 0)
+	for _, bg := range []color.Color{nil, red} {
+		m := pad(frames, padding, bg)[0]
+		w, h := m.Bounds().Dx(), m.Bounds().Dy()
+		if w != frames[0].Bounds().Dx()+2*padding || h != frames[0].Bounds().Dy()+2*padding {
+			t.Errorf("%v: expected the frame grown by %d on every side, got %dx%d", bg, padding, w, h)
+		}
+		border := color.Color(color.White) // the background of the graph, extended
+		if bg != nil {
+			border = bg
+		}
+		for _, p := range []image.Point{{0, 0}, {padding - 1, padding - 1}, {w - 1, h / 2}, {w / 2, h - 1}} {
+			if c := color.RGBAModel.Convert(m.At(p.X, p.Y)); c != color.RGBAModel.Convert(border) {
+				t.Errorf("%v: expected the border at %v in %v, got %v", bg, p, border, c)
+			}
+		}
+		// the frame is drawn inside the border
+		b := frames[0].Bounds()
+		for _, p := range []image.Point{{0, 0}, {b.Dx() / 2, b.Dy() / 2}, {b.Dx() - 1, b.Dy() - 1}} {
+			want := color.RGBAModel.Convert(frames[0].At(p.X, p.Y))
+			if c := color.RGBAModel.Convert(m.At(p.X+padding, p.Y+padding)); c != want {
+				t.Errorf("%v: expected the frame unchanged inside the border at %v, got %v", bg, p, c)
+			}
+		}
+	}
+}
+
+func TestMonthRange(t *testing.T) {
+	for _, tc := range []struct {
+		year, from, to, label string
+	}{
+		{"2020", "2020-01-01", "2020-12-31", "2020"},
+		{"2021-03:2021-09", "2021-03-01", "2021-09-30", "2021 Mar–Sep"},
+		{"2020-02:2020-02", "2020-02-01", "2020-02-29", "2020 Feb"},
+		{"2021-11:2022-02", "2021-11-01", "2022-02-28", "2021 Nov–2022 Feb"},
+	} {
+		if from, to := yearDates(tc.year); from != tc.from || to != tc.to {
+			t.Errorf("%s: expected the dates %s %s, got %s %s", tc.year, tc.from, tc.to, from, to)
+		}
+		if label := yearLabel(tc.year); label != tc.label {
+			t.Errorf("%s: expected the label %q, got %q", tc.year, tc.label, label)
+		}
+	}
+	if url := activityURL(githubURL, "octocat", "2021-03:2021-09"); !strings.HasSuffix(url, "from=2021-03-01&to=2021-09-30") {
+		t.Errorf("expected the URL bounded by the months, got %s", url)
+	}
+
+	for entry, want := range map[string]string{
+		"2021-03:2021-09": "",
+		"2021-09:2021-03": "2021-09 is after 2021-03",
+		"2021-3:2021-09":  "expected YYYY-MM:YYYY-MM",
+		"2021-13:2021-14": "parse month range",
+	} {
+		err := checkMonthRange(entry)
+		if want == "" && err != nil || want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: expected %q, got %v", entry, want, err)
+		}
+	}
+}
+func TestSizeByTotal(t *testing.T) {
+	for _, tc := range []struct {
+		total, max int
+		scale      float64
+	}{
+		{100, 100, 1},
+		{25, 100, 0.5}, // a quarter of the area
+		{0, 100, 0},
+		{100, 0, 1}, // not scaled
+	} {
+		if got := totalScale(tc.total, tc.max); math.Abs(got-tc.scale) > 1e-9 {
+			t.Errorf("%d of %d: expected the scale %g, got %g", tc.total, tc.max, tc.scale, got)
+		}
+	}
+
+	// the axes of the vertices scale, not the canvas
+	act := activity{Commits: 100, Issues: 100, Prs: 100, CodeReviews: 100, Total: 25}
+	full := coordinates(act, layout{Width: defaultWidth})
+	scaled := coordinates(act, layout{Width: defaultWidth, SizeByTotal: true, MaxTotal: 100})
+	if scaled.W != full.W || scaled.H != full.H || scaled.Mid != full.Mid {
+		t.Errorf("expected the canvas unchanged, got %+v", scaled)
+	}
+	for name, d := range map[string][2]float64{
+		"commits":     {full.Mid - full.CommitsX, scaled.Mid - scaled.CommitsX},
+		"issues":      {full.IssuesX - full.Mid, scaled.IssuesX - scaled.Mid},
+		"prs":         {full.PrsY - full.Mid, scaled.PrsY - scaled.Mid},
+		"codeReviews": {full.Mid - full.CodeReviewY, scaled.Mid - scaled.CodeReviewY},
+	} {
+		if math.Abs(d[1]-d[0]/2) > 1e-9 {
+			t.Errorf("%s: expected half the distance %g from the origin, got %g", name, d[0]/2, d[1])
+		}
+	}
+
+	// every frame is scaled by the largest total of all the frames
+	in := make(chan activity, len(sampleActivities))
+	for _, a := range sampleActivities {
+		in <- a
+	}
+	close(in)
+	largest := sampleActivities[len(sampleActivities)-1].Total
+	for g := range genGraph(in, nil, len(sampleActivities), false, false, layout{Width: defaultWidth, SizeByTotal: true}) {
+		full := coordinates(g.Data, layout{Width: defaultWidth})
+		want := (full.Mid - full.CommitsX) * math.Sqrt(float64(g.Data.Total)/float64(largest))
+		if got := g.Coords.Mid - g.Coords.CommitsX; math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: expected the commits %g from the origin, got %g", g.Data.Year, want, got)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	act, err := baselineActivity("sample", "2018", true, nil)
+	if err != nil || !reflect.DeepEqual(act, sampleActivities[2]) {
+		t.Errorf("expected the sample activity of 2018, got %+v %v", act, err)
+	}
+	if _, err := baselineActivity("sample", "2010", true, nil); err == nil || !strings.Contains(err.Error(), "no activity in 2010") {
+		t.Errorf("expected a year missing from the sample to fail, got %v", err)
+	}
+	failing := scraper(func(handle, year string) (activity, error) {
+		return activity{}, errors.New("not found")
+	})
+	if _, err := baselineActivity("octocat", "2018", false, failing); err == nil || !strings.Contains(err.Error(), "2018 is unavailable: not found") {
+		t.Errorf("expected the scrape error, got %v", err)
+	}
+
+	// the baseline reaches past the polygon of the year, where it is drawn faintly
+	base := coordinates(activity{Commits: 100, Prs: 100}, layout{Width: defaultWidth})
+	year := activity{Handle: "octocat", Year: "2020", Issues: 100}
+	g := graph{Data: year, Coords: coordinates(year, layout{Width: defaultWidth}), Baseline: &base}
+	s := testStyle(t)
+	x, y := int((base.CommitsX+base.Mid)/2), int(base.Mid+(base.PrsY-base.Mid)/4)
+	plain := img(graph{Data: g.Data, Coords: g.Coords}, s)
+	if c := img(g, s).At(x, y); c == plain.At(x, y) {
+		t.Errorf("expected the baseline drawn at %d,%d", x, y)
+	}
+}
+
+func TestFailures(t *testing.T) {
+	scrape := scraper(func(handle, year string) (activity, error) {
+		if year == "2020" {
+			return activity{Handle: handle, Year: year}, nil
+		}
+		return activity{}, errors.New("not found")
+	})
+	for _, show := range []bool{false, true} {
+		f := &failures{ShowHandle: show}
+		tracked := withFailures(scrape, f)
+		for _, handle := range []string{"octocat", "hubot"} {
+			for _, year := range []string{"2021-03:2021-09", "2020", "2019"} {
+				tracked(handle, year)
+			}
+		}
+		want := []string{"2019", "2021 Mar–Sep", "2019", "2021 Mar–Sep"}
+		if show {
+			want = []string{"hubot 2019", "hubot 2021 Mar–Sep", "octocat 2019", "octocat 2021 Mar–Sep"}
+		}
+		if got := f.list(); !reflect.DeepEqual(got, want) {
+			t.Errorf("show handle %v: expected %v, got %v", show, want, got)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := defaultStyle()
+	bounds := image.Rect(0, 0, int(defaultWidth), int(defaultHeight))
+	for _, handle := range []string{"octocat", "a-very-long-github-username-vs-another-one"} {
+		m := titleImg(handle, bounds, s, font)
+		if m.Bounds() != bounds {
+			t.Errorf("%s: expected a frame of %v, got %v", handle, bounds, m.Bounds())
+		}
+		if !hasColor(m, s.LabelColor) {
+			t.Errorf("%s: expected the handle drawn in the label color", handle)
+		}
+		// the handle fits the frame, away from its edges
+		white := color.RGBAModel.Convert(color.White)
+		for y := 0; y < bounds.Dy(); y++ {
+			if color.RGBAModel.Convert(m.At(0, y)) != white || color.RGBAModel.Convert(m.At(bounds.Dx()-1, y)) != white {
+				t.Errorf("%s: expected the handle to fit the frame, drawn at the edge on row %d", handle, y)
+				break
+			}
+		}
+	}
+
+	frames := sampleFrames(t, 0)
+	path, err := createGIF(context.Background(), frames, t.TempDir(), "sample", "gif", gifOptions{FirstDelay: 200, Delay: 40, FinalDelay: 300, Palette: palette.Plan9}, encodeGIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delays := decodeGIF(t, path).Delay
+	if delays[0] != 200 || delays[1] != 40 || delays[len(delays)-1] != 300 {
+		t.Errorf("expected the first frame held for 200, got the delays %v", delays)
+	}
+}
+
+func TestTransparent(t *testing.T) {
+	act := sampleActivities[4]
+	g := graph{Data: act, Coords: coordinates(act, layout{Width: defaultWidth})}
+	s := testStyle(t)
+	s.Transparent = true
+	frame := img(g, s)
+	if _, _, _, a := frame.At(0, 0).RGBA(); a != 0 {
+		t.Errorf("expected the background transparent, got the alpha %d", a)
+	}
+
+	path, err := createGIF(context.Background(), []image.Image{frame, frame}, t.TempDir(), "sample", "gif", gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 40, Transparent: true, Palette: gifPalette("plan9", []image.Image{frame}, true)}, encodeGIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	anim := decodeGIF(t, path)
+	for i, m := range anim.Image {
+		if _, _, _, a := m.At(0, 0).RGBA(); a != 0 {
+			t.Errorf("frame %d: expected the background kept transparent, got the alpha %d", i, a)
+		}
+		if anim.Disposal[i] != gif.DisposalBackground {
+			t.Errorf("frame %d: expected the frame cleared before the next, got the disposal %d", i, anim.Disposal[i])
+		}
+	}
+	mid := int(g.Coords.Mid)
+	if _, _, _, a := anim.Image[0].At(mid, mid).RGBA(); a == 0 {
+		t.Error("expected the graph drawn over the transparent background")
+	}
+}
+
+func TestLoadFont(t *testing.T) {
+	defer silence()()
+
+	dir := t.TempDir()
+	custom := filepath.Join(dir, "custom.ttf")
+	if err := ioutil.WriteFile(custom, gomono.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.ttf")
+	if err := ioutil.WriteFile(broken, []byte("not a font"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	name := func(f *truetype.Font) string { return f.Name(truetype.NameIDFontFullName) }
+
+	f, err := loadFont(custom)
+	if err != nil || name(f) != "Go Mono" {
+		t.Errorf("expected the font of the file, got %v", err)
+	}
+	for _, path := range []string{"", broken, filepath.Join(dir, "missing.ttf")} {
+		if f, err := loadFont(path); err != nil || name(f) != "Go Regular" {
+			t.Errorf("font %q: expected a fallback to Go Regular, got %v", path, err)
+		}
+	}
+}
+
+func TestFontSizes(t *testing.T) {
+	ttf, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height := func(f font.Face) int { return f.Metrics().Height.Ceil() }
+	base := withFonts(defaultStyle(), ttf)
+
+	s := defaultStyle()
+	s.LabelSize, s.ValueSize = 48, 11
+	s = withFonts(s, ttf)
+	if height(s.LabelFont) <= height(base.LabelFont) || height(s.ValueFont) >= height(base.ValueFont) {
+		t.Errorf("expected the sizes overridden, got the heights %d and %d", height(s.LabelFont), height(s.ValueFont))
+	}
+
+	// the overrides do not scale with the canvas
+	scaled := defaultStyle()
+	scaled.LabelSize = 48
+	scaled = withFonts(scaleStyle(scaled, 2), ttf)
+	if height(scaled.LabelFont) != height(s.LabelFont) {
+		t.Errorf("expected the label size kept on a larger canvas, got the height %d", height(scaled.LabelFont))
+	}
+	if height(scaled.ValueFont) <= height(base.ValueFont) {
+		t.Errorf("expected the default value size scaled, got the height %d", height(scaled.ValueFont))
+	}
+}
+
+func TestEncodeGIFWriter(t *testing.T) {
+	frames := sampleFrames(t, 0)
+	var buf bytes.Buffer
+	if err := encodeGIF(context.Background(), &buf, frames, gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 40, Palette: palette.Plan9}); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(anim.Image) != len(frames) {
+		t.Errorf("expected %d frames streamed, got %d", len(frames), len(anim.Image))
+	}
+}
+
+func TestCreateGIFError(t *testing.T) {
+	// a directory where the GIF should be fails with an error instead of exiting the process
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "octocat.gif"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	frames := []image.Image{image.NewRGBA(image.Rect(0, 0, 10, 10))}
+	if _, err := createGIF(context.Background(), frames, dir, "octocat", "gif", gifOptions{Palette: palette.Plan9}, encodeGIF); err == nil {
+		t.Error("expected an error creating the GIF over a directory")
+	}
+}
+
+func TestNoActivity(t *testing.T) {
+	s := testStyle(t)
+	empty := activity{Handle: "octocat", Year: "2020"}
+	m := img(graph{Data: empty, Coords: coordinates(empty, layout{Width: defaultWidth})}, s)
+	if hasColor(m, s.AxisColor) || hasColor(m, s.PolyColor) {
+		t.Error("expected the chart left out of a year without contributions")
+	}
+	// the message is centered on the graph
+	c := coordinates(empty, layout{Width: defaultWidth})
+	center := image.Rect(int(c.Mid-c.Factor), int(c.Mid-c.Factor/2), int(c.Mid+c.Factor), int(c.Mid+c.Factor/2))
+	if !hasColor(m.(*image.RGBA).SubImage(center), s.ValueColor) {
+		t.Error("expected the No activity message at the center of the graph")
+	}
+
+	if noActivity(activity{CodeReviews: 1}) {
+		t.Error("expected a year with contributions to have activity")
+	}
+}
+
+func TestCheckHandle(t *testing.T) {
+	defer silence()()
+
+	var checked []string
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("expected a HEAD request, got %s", r.Method)
+		}
+		checked = append(checked, r.URL.Path)
+		switch r.URL.Path {
+		case "/ghost":
+			w.WriteHeader(http.StatusNotFound)
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	opts := fetchOptions{MaxBytes: 1 << 20}
+
+	for _, tc := range []struct {
+		handle, err string
+	}{
+		{"octocat", ""},
+		{"Octo-Cat-2", ""},
+		{"a", ""},
+		{strings.Repeat("a", maxHandleLength), ""},
+		{strings.Repeat("a", maxHandleLength+1), "at most 39 characters"},
+		{"", "single hyphens"},
+		{"-octocat", "neither first nor last"},
+		{"octocat-", "neither first nor last"},
+		{"octo--cat", "single hyphens"},
+		{"octo_cat", "alphanumeric"},
+		{"octo cat", "alphanumeric"},
+		{"unavailable", ""}, // only logged, so that cached runs work offline
+	} {
+		err := checkHandle(tc.handle, opts)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: %v", tc.handle, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%q: expected %q, got %v", tc.handle, tc.err, err)
+		}
+	}
+	if want := []string{"/octocat", "/Octo-Cat-2", "/a", "/" + strings.Repeat("a", maxHandleLength), "/unavailable"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("expected only the valid handles looked up, got %v", checked)
+	}
+
+	err := checkHandle("ghost", opts)
+	if !errors.Is(err, ErrUserNotFound) || !strings.Contains(err.Error(), "ghost") {
+		t.Errorf("expected %v for a missing profile, got %v", ErrUserNotFound, err)
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	for _, tc := range []struct {
+		host, want string
+	}{
+		{"github.com", githubURL},
+		{"github.example.com", "https://github.example.com"},
+		{"https://github.example.com/", "https://github.example.com"},
+		{"http://localhost:8080", "http://localhost:8080"},
+		{"ftp://github.example.com", ""},
+		{"github.example.com/octocat", ""},
+		{"user@github.example.com", ""},
+		{"https://", ""},
+	} {
+		got, err := parseHost(tc.host)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("%q: expected an invalid host, got %q", tc.host, got)
+		case tc.want != "" && (err != nil || got != tc.want):
+			t.Errorf("%q: expected %q, got %q, %v", tc.host, tc.want, got, err)
+		}
+	}
+
+	enterprise := fetchOptions{BaseURL: "https://github.example.com"}
+	for got, want := range map[string]string{
+		fetchOptions{}.webURL("/octocat"):                     "https://github.com/octocat",
+		fetchOptions{}.apiURL("/users/octocat"):               "https://api.github.com/users/octocat",
+		enterprise.webURL("/octocat"):                         "https://github.example.com/octocat",
+		enterprise.apiURL("/users/octocat"):                   "https://github.example.com/api/v3/users/octocat",
+		activityURL(enterprise.webURL(""), "octocat", "2020"): "https://github.example.com/octocat?tab=overview&from=2020-01-01&to=2020-12-31",
+	} {
+		if got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+}
+
+func TestEnterpriseHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/octocat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, overviewFixture)
+	}))
+	defer srv.Close()
+
+	opts := fetchOptions{BaseURL: srv.URL, MaxBytes: 1 << 20, MarkupVersion: "auto"}
+	if err := checkHandle("octocat", opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkHandle("ghost", opts); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected %v from the instance, got %v", ErrUserNotFound, err)
+	}
+	act, err := parseActivity("octocat", "2020", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Commits != 60 || act.CodeReviews != 10 {
+		t.Errorf("expected the activity scraped from the instance, got %+v", act)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		palette     string
+		transparent bool
+		compact     bool
+		encode      encoder
+	}{
+		{"plan9", "plan9", false, false, encodeGIF},
+		{"adaptive", "adaptive", false, false, encodeGIF},
+		{"adaptive transparent", "adaptive", true, false, encodeGIF},
+		{"compact", "plan9", false, true, encodeGIF},
+		{"webp", "plan9", false, false, encodeWebP},
+	} {
+		var outputs [][]byte
+		for run := 0; run < 2; run++ {
+			// every run renders its own frames, as separate invocations would
+			frames := sampleFrames(t, 0)
+			opts := gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 300, Compact: tc.compact, Transparent: tc.transparent, Palette: gifPalette(tc.palette, frames, tc.transparent)}
+			var buf bytes.Buffer
+			if err := tc.encode(context.Background(), &buf, frames, opts); err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			outputs = append(outputs, buf.Bytes())
+		}
+		if !bytes.Equal(outputs[0], outputs[1]) {
+			t.Errorf("%s: expected byte-identical outputs across runs", tc.name)
+		}
+	}
+}
+
+func TestBaselineAllFrames(t *testing.T) {
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := withFonts(defaultStyle(), font)
+	base := sampleActivities[4]
+	acts := []activity{sampleActivities[0], {Handle: "sample", Year: "2021"}}
+	l := layout{Width: defaultWidth, SizeByTotal: true}
+
+	in := make(chan activity, len(acts))
+	for _, act := range acts {
+		in <- act
+	}
+	close(in)
+	// the baseline has the largest total, the graphs are scaled by it
+	scaled := l
+	scaled.MaxTotal = base.Total
+	want := coordinates(base, scaled)
+	for g := range genGraph(in, &base, len(acts), false, false, l) {
+		if g.Baseline == nil || !reflect.DeepEqual(*g.Baseline, want) {
+			t.Errorf("%s: expected the baseline %+v, got %+v", g.Data.Year, want, g.Baseline)
+			continue
+		}
+		without := g
+		without.Baseline = nil
+		if sameImage(img(g, s), img(without, s)) {
+			t.Errorf("%s: expected the baseline drawn", g.Data.Year)
+		}
+	}
+
+	with, err := spinImgs(context.Background(), acts[0], &base, 4, scaled, s, font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	without, err := spinImgs(context.Background(), acts[0], nil, 4, scaled, s, font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range with {
+		if sameImage(with[i], without[i]) {
+			t.Errorf("spin frame %d: expected the baseline drawn", i)
+		}
+	}
+}
+
+func TestEncodeGIFDisposal(t *testing.T) {
+	frames := sampleFrames(t, 1)
+	for _, tc := range []struct {
+		opts gifOptions
+		want byte
+	}{
+		{gifOptions{}, 0}, // unspecified, as before --compact-frames
+		{gifOptions{Compact: true}, gif.DisposalNone},
+		{gifOptions{Transparent: true}, gif.DisposalBackground},
+	} {
+		opts := tc.opts
+		opts.Delay, opts.FirstDelay, opts.FinalDelay = 100, 100, 100
+		opts.Palette = gifPalette("adaptive", frames, opts.Transparent)
+		var buf bytes.Buffer
+		if err := encodeGIF(context.Background(), &buf, frames, opts); err != nil {
+			t.Fatal(err)
+		}
+		anim, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, d := range anim.Disposal {
+			if d != tc.want {
+				t.Errorf("%+v frame %d: expected the disposal %d, got %d", tc.opts, i, tc.want, d)
+			}
+		}
+	}
+}
+
+func BenchmarkEncodeGIF(b *testing.B) {
+	frames := sampleFrames(b, 3)
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%v", compact), func(b *testing.B) {
+			opts := gifOptions{Delay: 100, FirstDelay: 100, FinalDelay: 100, Compact: compact, Palette: palette.Plan9}
+			var buf bytes.Buffer
+			for i := 0; i < b.N; i++ {
+				buf.Reset()
+				if err := encodeGIF(context.Background(), &buf, frames, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(buf.Len()), "bytes/gif")
+		})
+	}
+}
+
+func TestImgNoActivity(t *testing.T) {
+	s := testStyle(t)
+	empty := activity{Handle: "sample", Year: "2021"}
+	g := graph{Data: empty, Coords: coordinates(empty, layout{Width: defaultWidth})}
+	plain := img(g, s)
+
+	grid := s
+	grid.Grid = true
+	legend := s
+	legend.Legend = true
+	// with --spin-labels rotate the message turns with the chart
+	rotate := s
+	rotate.UprightLabels = false
+	turned := g
+	turned.Rotation = math.Pi / 4
+	for name, frame := range map[string]image.Image{
+		"grid":     img(g, grid),
+		"legend":   img(g, legend),
+		"rotation": img(turned, rotate),
+	} {
+		if sameImage(frame, plain) {
+			t.Errorf("%s: expected it drawn on the frame without activity", name)
+		}
+	}
+}
+
+func TestParseActivityRedirect(t *testing.T) {
+	for _, tc := range []struct {
+		target, want string
+	}{
+		{"/OctoCat", "OctoCat"},   // the profile in its casing
+		{"/login", "octocat"},     // not the profile
+		{"/orgs/octo", "octocat"}, // not a single segment
+		{"/octodog", "octocat"},   // another profile
+	} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/octocat", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, tc.target, http.StatusMovedPermanently)
+		})
+		mux.HandleFunc(tc.target, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, overviewFixture)
+		})
+		srv := httptest.NewServer(mux)
+		act, err := parseActivity("octocat", "2020", fetchOptions{BaseURL: srv.URL, MaxBytes: 1 << 20, MarkupVersion: "auto"})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.target, err)
+		}
+		if act.Handle != tc.want {
+			t.Errorf("redirect to %s: expected the handle %s, got %s", tc.target, tc.want, act.Handle)
+		}
+		if act.Commits != 60 || act.Prs != 20 {
+			t.Errorf("redirect to %s: expected the activity of the fixture, got %+v", tc.target, act)
+		}
+	}
+}
+
+func TestParseYearFlagRange(t *testing.T) {
+	// the years are known, the profile is not fetched to discover them
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no request, got %s", r.URL)
+	})
+	years, err := parseYearFlag("2019-2020", "octocat", fetchOptions{MaxBytes: 1 << 20})
+	if err != nil || !reflect.DeepEqual(years, []string{"2019", "2020"}) {
+		t.Errorf("expected 2019 and 2020, got %v %v", years, err)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	o := DefaultGenerateOptions()
+	o.Sample, o.OutDir, o.Quiet = true, t.TempDir(), true
+	if err := Generate(context.Background(), o); err != nil {
+		t.Fatal(err)
+	}
+	anim := decodeGIF(t, filepath.Join(o.OutDir, "sample.gif"))
+	if len(anim.Image) != len(sampleActivities) {
+		t.Errorf("expected a frame per year of the sample, got %d", len(anim.Image))
+	}
+	if anim.Delay[0] != o.Delay {
+		t.Errorf("expected the delay %d, got %d", o.Delay, anim.Delay[0])
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	// the options are rejected before any request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected no request, got %s", r.URL)
+	}))
+	defer srv.Close()
+	compare := func(o *GenerateOptions) {
+		o.Sample = false
+		o.Handles = []string{"octocat", "monalisa"}
+	}
+	for _, tc := range []struct {
+		name string
+		set  func(o *GenerateOptions)
+		err  string
+	}{
+		{"no handle", func(o *GenerateOptions) { o.Sample = false }, "missing the GitHub-username"},
+		{"since join compare", func(o *GenerateOptions) { compare(o); o.SinceJoin = true }, "--since-join and --compare-users"},
+		{"negative timeout", func(o *GenerateOptions) { o.Timeout = -time.Second }, "invalid timeout"},
+		{"stdout sidecar", func(o *GenerateOptions) { o.Stdout, o.Sidecar = true, true }, "--stdout and --sidecar"},
+		{"stdout live", func(o *GenerateOptions) { o.Stdout, o.Live = true, 2 }, "--stdout and --live"},
+		{"webp compact", func(o *GenerateOptions) { o.Format, o.CompactFrames = "webp", true }, "--compact-frames only applies to GIFs"},
+		{"reproducible since join", func(o *GenerateOptions) { o.Reproducible, o.SinceJoin = true, true }, "--reproducible and --since-join"},
+		{"negative final delay", func(o *GenerateOptions) { o.FinalDelay = -1 }, "invalid final delay"},
+		{"negative title delay", func(o *GenerateOptions) { o.TitleDelay = -1 }, "invalid title delay"},
+		{"negative label size", func(o *GenerateOptions) { o.LabelSize = -1 }, "invalid label size"},
+		{"extension", func(o *GenerateOptions) { o.Extension = "g/f" }, "extension"},
+	} {
+		o := DefaultGenerateOptions()
+		o.Sample, o.OutDir, o.Quiet, o.Host = true, t.TempDir(), true, srv.URL
+		tc.set(&o)
+		if err := Generate(context.Background(), o); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestQuietLogger(t *testing.T) {
+	var std, own bytes.Buffer
+	log.SetOutput(&std)
+	defer log.SetOutput(os.Stderr)
+	out := logger.Writer()
+	logger.SetOutput(&own)
+	defer logger.SetOutput(out)
+
+	// a program using the package keeps its own logs during a quiet run
+	restore := silence()
+	log.Print("logged by the program")
+	logger.Print("logged by gifhub")
+	restore()
+	if !strings.Contains(std.String(), "logged by the program") {
+		t.Errorf("expected the standard logger untouched by --quiet, got %q", std.String())
+	}
+	if own.Len() > 0 {
+		t.Errorf("expected nothing logged by the quiet run, got %q", own.String())
+	}
+	if logger.Writer() != &own {
+		t.Error("expected the output of the logger restored once the run is over")
+	}
+}
+
+func TestSelfCheckHost(t *testing.T) {
+	enterprise := fetchOptions{BaseURL: "https://github.example.com", MaxBytes: 1 << 20}
+	for _, tc := range []struct {
+		handle string
+		opts   fetchOptions
+		want   string
+	}{
+		{"", fetchOptions{}, selfCheckHandle},
+		{"octocat", fetchOptions{}, "octocat"},
+		{"octocat", enterprise, "octocat"},
+		{"", enterprise, ""}, // the reference profile is one of GitHub, not of the instance
+	} {
+		got, err := selfCheckUser(tc.handle, tc.opts)
+		switch {
+		case tc.want == "" && (err == nil || !strings.Contains(err.Error(), "pass the GitHub-username")):
+			t.Errorf("%q on %q: expected a GitHub-username to be required, got %q %v", tc.handle, tc.opts.BaseURL, got, err)
+		case tc.want != "" && (err != nil || got != tc.want):
+			t.Errorf("%q on %q: expected %q, got %q %v", tc.handle, tc.opts.BaseURL, tc.want, got, err)
+		}
+	}
+
+	var checked []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checked = append(checked, r.URL.Path)
+		fmt.Fprint(w, overviewFixture)
+	}))
+	defer srv.Close()
+	if err := selfCheck("octocat", "2020", fetchOptions{BaseURL: srv.URL, MaxBytes: 1 << 20, MarkupVersion: "auto"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(checked) != 1 || checked[0] != "/octocat" {
+		t.Errorf("expected the profile of octocat checked on the instance, got %v", checked)
+	}
+}
@@ -0,0 +1,102 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultCacheTTL is how long the activity of a year that is not over yet stays cached
+const defaultCacheTTL = time.Hour
+
+// activityCache stores the scraped activities as a JSON file per kind of scrape, handle and year in Dir
+// the activity of a year that is over never changes and is kept indefinitely, that of the current year for TTL
+type activityCache struct {
+	Dir  string
+	Kind string // the backend, calendar or streak, which scrape different activities of the same year
+	TTL  time.Duration
+}
+
+// defaultCacheDir returns the gifhub directory of the user's cache directory, e.g. ~/.cache/gifhub
+func defaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gifhub"), nil
+}
+
+// path returns the file of the activity of handle on year, GitHub handles are case-insensitive
+func (c activityCache) path(handle, year string) string {
+	// colons are not valid in Windows file names
+	name := fmt.Sprintf("%s-%s-%s.json", c.Kind, strings.ToLower(handle), strings.Replace(year, ":", "_", -1))
+	return filepath.Join(c.Dir, name)
+}
+
+// load returns the cached activity of handle on year, false if it is not cached or stale
+// an activity cached once the year was over is never stale, one cached during the year is after TTL, even once the year is over
+func (c activityCache) load(handle, year string) (activity, bool) {
+	path := c.path(handle, year)
+	info, err := os.Stat(path)
+	if err != nil {
+		return activity{}, false
+	}
+	if !info.ModTime().After(yearEnd(year)) && time.Since(info.ModTime()) > c.TTL {
+		return activity{}, false
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return activity{}, false
+	}
+	var act activity
+	if err := json.Unmarshal(data, &act); err != nil {
+		return activity{}, false
+	}
+	act.Handle = handle
+	return act, true
+}
+
+// yearEnd returns the time a year, or range of months, is over: the midnight after its last day
+// a year that cannot be parsed is never over
+func yearEnd(year string) time.Time {
+	_, to := yearDates(year)
+	last, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return time.Now().AddDate(100, 0, 0)
+	}
+	return last.AddDate(0, 0, 1)
+}
+
+// store caches the activity of handle on year
+func (c activityCache) store(handle, year string, act activity) error {
+	if err := os.MkdirAll(c.Dir, os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.Marshal(act)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path(handle, year), data, 0644)
+}
+
+// withCache returns a scraper answering from c, which caches the successful scrapes of scrape
+func withCache(scrape scraper, c activityCache) scraper {
+	return func(handle, year string) (activity, error) {
+		if act, ok := c.load(handle, year); ok {
+			logger.Printf("Cached activity for %s\n", year)
+			return act, nil
+		}
+		act, err := scrape(handle, year)
+		if err != nil {
+			return act, err
+		}
+		if err := c.store(handle, year, act); err != nil {
+			logger.Printf("cache: %v\n", err)
+		}
+		return act, nil
+	}
+}
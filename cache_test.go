@@ -0,0 +1,103 @@
+package gifhub
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestActivityCache(t *testing.T) {
+	c := activityCache{Dir: t.TempDir(), Kind: "html", TTL: time.Hour}
+	act := activity{Handle: "octocat", Year: "2019", Commits: 80, Issues: 10, Prs: 5, CodeReviews: 5}
+	current := time.Now().Format("2006")
+
+	for _, tc := range []struct {
+		year    string
+		written time.Time
+		fresh   bool
+	}{
+		{"2019", time.Now().Add(-24 * 365 * time.Hour), true},
+		{current, time.Now().Add(-time.Minute), true},
+		{current, time.Now().Add(-2 * time.Hour), false},
+	} {
+		if err := c.store("octocat", tc.year, act); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(c.path("octocat", tc.year), tc.written, tc.written); err != nil {
+			t.Fatal(err)
+		}
+		got, ok := c.load("OctoCat", tc.year)
+		if ok != tc.fresh {
+			t.Errorf("%s written %v ago: expected fresh %v, got %v", tc.year, time.Since(tc.written).Round(time.Minute), tc.fresh, ok)
+		}
+		if ok && (got.Handle != "OctoCat" || got.Commits != act.Commits) {
+			t.Errorf("%s: expected %+v labeled OctoCat, got %+v", tc.year, act, got)
+		}
+	}
+
+	// the kinds of scrapes are cached apart
+	if _, ok := (activityCache{Dir: c.Dir, Kind: "graphql", TTL: time.Hour}).load("octocat", "2019"); ok {
+		t.Error("expected the activity of another kind of scrape not to be cached")
+	}
+}
+
+func TestWithCache(t *testing.T) {
+	scrapes := 0
+	scrape := withCache(func(handle, year string) (activity, error) {
+		scrapes++
+		if year == "2018" {
+			return activity{}, errors.New("not found")
+		}
+		return activity{Handle: handle, Year: year, Commits: 100}, nil
+	}, activityCache{Dir: t.TempDir(), Kind: "html", TTL: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		if act, err := scrape("octocat", "2019"); err != nil || act.Commits != 100 {
+			t.Errorf("expected the activity, got %+v %v", act, err)
+		}
+		if _, err := scrape("octocat", "2018"); err == nil {
+			t.Error("expected the failed scrape not to be cached")
+		}
+	}
+	if scrapes != 3 {
+		t.Errorf("expected the successful scrape cached and the failed one repeated, got %d scrapes", scrapes)
+	}
+}
+
+func TestActivityCacheStale(t *testing.T) {
+	c := activityCache{Dir: t.TempDir(), Kind: "html", TTL: time.Hour}
+	act := activity{Handle: "octocat", Year: "2019", Commits: 80, Issues: 10, Prs: 5, CodeReviews: 5}
+
+	tests := []struct {
+		name    string
+		year    string
+		written time.Time
+		fresh   bool
+	}{
+		{"written after the year", "2019", time.Date(2020, 3, 1, 0, 0, 0, 0, time.Local), true},
+		{"written during the year", "2019", time.Date(2019, 12, 20, 0, 0, 0, 0, time.Local), false},
+		{"written on the last day", "2019", time.Date(2019, 12, 31, 23, 0, 0, 0, time.Local), false},
+		{"written during the months", "2019-03:2019-05", time.Date(2019, 5, 30, 0, 0, 0, 0, time.Local), false},
+		{"written after the months", "2019-03:2019-05", time.Date(2019, 6, 2, 0, 0, 0, 0, time.Local), true},
+		{"current year within the TTL", time.Now().Format("2006"), time.Now().Add(-time.Minute), true},
+		{"current year past the TTL", time.Now().Format("2006"), time.Now().Add(-2 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.store("octocat", tt.year, act); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(c.path("octocat", tt.year), tt.written, tt.written); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := c.load("OctoCat", tt.year)
+			if ok != tt.fresh {
+				t.Fatalf("expected fresh %v, got %v", tt.fresh, ok)
+			}
+			if ok && (got.Handle != "OctoCat" || got.Commits != act.Commits) {
+				t.Errorf("expected %+v labeled OctoCat, got %+v", act, got)
+			}
+		})
+	}
+}
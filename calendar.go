@@ -0,0 +1,199 @@
+package gifhub
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// day contains the contributions of a user on a day of the contributions calendar
+type day struct {
+	Date  string // YYYY-MM-DD
+	Level int    // intensity of the cell, from 0 (no contributions) to 4
+}
+
+// calendarURL returns the URL of the contributions calendar of a GitHub user on a given year, or range of months
+// on the GitHub instance at base
+func calendarURL(base, userHandle, year string) string {
+	from, to := yearDates(year)
+	return fmt.Sprintf("%s/users/%s/contributions?from=%s&to=%s", base, userHandle, from, to)
+}
+
+// parseCalendar returns the days of the contributions calendar of a GitHub user on a given year
+func parseCalendar(userHandle, year string, opts fetchOptions) ([]day, error) {
+	url := calendarURL(opts.webURL(""), userHandle, year)
+	body, err := html(url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return scrapeCalendar(body)
+}
+
+// calendarActivity returns an activity holding the contributions calendar of a GitHub user on a given year
+func calendarActivity(userHandle, year string, opts fetchOptions) (activity, error) {
+	days, err := parseCalendar(userHandle, year, opts)
+	if err != nil {
+		return activity{}, err
+	}
+	return activity{Handle: userHandle, Year: year, Days: days, Streak: longestStreak(days)}, nil
+}
+
+// unknownStreak is the streak of an activity whose calendar failed to be scraped, told apart from a streak of 0 days
+const unknownStreak = -1
+
+// withStreak returns a scraper adding the longest streak of the year to the activities returned by scrape
+// the streak is unknownStreak if the calendar fails to be scraped
+func withStreak(scrape scraper, opts fetchOptions) scraper {
+	return func(handle, year string) (activity, error) {
+		act, err := scrape(handle, year)
+		if err != nil {
+			return act, err
+		}
+		days, err := parseCalendar(handle, year, opts)
+		if err != nil {
+			logger.Printf("scrape calendar for %s: %v\n", year, err)
+			act.Streak = unknownStreak
+			return act, nil
+		}
+		act.Streak = longestStreak(days)
+		return act, nil
+	}
+}
+
+// streakLabel returns the streak in days, or unknown if the calendar failed to be scraped
+func streakLabel(streak int) string {
+	if streak == unknownStreak {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d days", streak)
+}
+
+// scrapeCalendar returns the days of a contributions calendar HTML text
+// the days are returned in chronological order
+func scrapeCalendar(html []byte) ([]day, error) {
+	startTag := []byte("<")
+	dateAttr := []byte("data-date=\"")
+	levelAttr := []byte("data-level=\"")
+	quote := []byte("\"")
+
+	// the attribute order differs between markup versions, so look for both in every tag
+	days := []day{}
+	for _, tag := range bytes.Split(html, startTag) {
+		if !bytes.Contains(tag, dateAttr) {
+			continue
+		}
+		date, err := extractBetween(tag, dateAttr, quote)
+		if err != nil {
+			return nil, err
+		}
+		rawLevel, err := extractBetween(tag, levelAttr, quote)
+		if err != nil {
+			return nil, err
+		}
+		level, err := parseScrapedInt(rawLevel)
+		if err != nil {
+			return nil, fmt.Errorf("contribution level of %s: %v", date, err)
+		}
+		days = append(days, day{string(date), level})
+	}
+	if len(days) == 0 {
+		return nil, patternNotFound(dateAttr)
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+
+	return days, nil
+}
+
+// longestStreak returns the longest run of consecutive days with contributions
+// days must be in chronological order
+func longestStreak(days []day) int {
+	longest, current := 0, 0
+	var previous time.Time
+	for _, d := range days {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil || d.Level == 0 {
+			current = 0
+			continue
+		}
+		if current > 0 && date.Sub(previous) == 24*time.Hour {
+			current++
+		} else {
+			current = 1
+		}
+		previous = date
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// calendarColors are the colors of the contribution levels of GitHub's contributions calendar
+var calendarColors = []color.Color{
+	color.RGBA{235, 237, 240, 0xff},
+	color.RGBA{155, 233, 168, 0xff},
+	color.RGBA{64, 196, 99, 0xff},
+	color.RGBA{48, 161, 78, 0xff},
+	color.RGBA{33, 110, 57, 0xff},
+}
+
+// calendarImg generates an image of the contributions calendar of graph g with the styles defined in s
+// the calendar has a column per week and a row per weekday, starting on Sunday
+func calendarImg(g graph, s style) image.Image {
+	const (
+		cell   = 10.0 // side of a day
+		step   = 13.0 // distance between neighbouring days
+		margin = 20.0
+		weeks  = 54 // a year spans 54 partial weeks at most
+	)
+	w := 2*margin + weeks*step
+	h := 2*margin + 7*step + 2*margin
+
+	dc := gg.NewContext(int(w), int(h))
+	if !s.Transparent {
+		dc.SetColor(color.White)
+		dc.Clear()
+	}
+
+	// the first column holds the week of January 1st, or of the first day of a range of months
+	from, _ := yearDates(g.Data.Year)
+	start, err := time.Parse("2006-01-02", from)
+	if err == nil {
+		start = start.AddDate(0, 0, -int(start.Weekday()))
+	}
+	for _, d := range g.Data.Days {
+		date, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			continue
+		}
+		week := int(date.Sub(start).Hours() / 24 / 7)
+		if week < 0 || week >= weeks {
+			continue
+		}
+		level := d.Level
+		if level < 0 || level >= len(calendarColors) {
+			level = len(calendarColors) - 1
+		}
+		dc.SetColor(calendarColors[level])
+		dc.DrawRoundedRectangle(margin+float64(week)*step, margin+float64(date.Weekday())*step, cell, cell, 2)
+		dc.Fill()
+	}
+
+	if !s.NoLabels {
+		dc.SetFontFace(s.LabelFont)
+		dc.SetColor(s.LabelColor)
+		dc.DrawStringAnchored(g.Data.Handle, margin, h-1.5*margin, 0, 0.5)
+		dc.DrawStringAnchored(yearLabel(g.Data.Year), w-margin, h-1.5*margin, 1, 0.5)
+	}
+
+	return dc.Image()
+}
@@ -0,0 +1,63 @@
+package gifhub
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// calendarFixture is a contributions calendar with a streak of 3 days
+const calendarFixture = `<table>
+<td data-date="2020-01-01" data-level="1"></td>
+<td data-date="2020-01-02" data-level="2"></td>
+<td data-date="2020-01-03" data-level="4"></td>
+<td data-date="2020-01-04" data-level="0"></td>
+<td data-date="2020-01-05" data-level="1"></td>
+</table>`
+
+func TestWithStreak(t *testing.T) {
+	scrape := func(handle, year string) (activity, error) {
+		return activity{Handle: handle, Year: year, Commits: 100}, nil
+	}
+	for _, tc := range []struct {
+		status int
+		streak int
+		label  string
+	}{
+		{http.StatusOK, 3, "3 days"},
+		{http.StatusNotFound, unknownStreak, "unknown"},
+	} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			fmt.Fprint(w, calendarFixture)
+		}))
+		act, err := withStreak(scrape, fetchOptions{BaseURL: srv.URL, MaxBytes: 1 << 20})("octocat", "2020")
+		srv.Close()
+		if err != nil {
+			t.Fatalf("status %d: expected the activity despite the calendar, got %v", tc.status, err)
+		}
+		if act.Streak != tc.streak {
+			t.Errorf("status %d: expected the streak %d, got %d", tc.status, tc.streak, act.Streak)
+		}
+		if label := streakLabel(act.Streak); label != tc.label {
+			t.Errorf("status %d: expected the streak label %q, got %q", tc.status, tc.label, label)
+		}
+	}
+}
+
+func TestAverageActivityStreak(t *testing.T) {
+	for _, tc := range []struct {
+		streaks []int
+		want    int
+	}{
+		{[]int{unknownStreak, unknownStreak}, unknownStreak},
+		{[]int{unknownStreak, 5}, 5},
+		{[]int{0, 0}, 0},
+	} {
+		acts := []activity{{Year: "2019", Streak: tc.streaks[0]}, {Year: "2020", Streak: tc.streaks[1]}}
+		if got := averageActivity(acts).Streak; got != tc.want {
+			t.Errorf("%v: expected the streak %d, got %d", tc.streaks, tc.want, got)
+		}
+	}
+}
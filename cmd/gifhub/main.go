@@ -0,0 +1,455 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/CamiloGarciaLaRotta/gifhub"
+	"github.com/urfave/cli/v2"
+)
+
+func main() {
+	if err := newApp().Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newApp returns the command line app of gifhub, whose flags default to gifhub.DefaultGenerateOptions
+func newApp() *cli.App {
+	defaults := gifhub.DefaultGenerateOptions()
+	app := cli.NewApp()
+	app.Name = "gifhub"
+	app.Usage = "Create GIFs from a people's GitHub activity graph"
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:    "years",
+			Aliases: []string{"y"},
+			Value:   defaults.Years,
+			Usage:   "Scrape activityfrom years `2016,2017,2019` or ranges of years 2016-2019, or from a file @years.txt",
+		},
+		&cli.IntFlag{
+			Name:  "years-from",
+			Usage: "Scrape activity from `year` up to the current year, without discovering the available years",
+		},
+		&cli.BoolFlag{
+			Name:    "reverse",
+			Aliases: []string{"r"},
+			Usage:   "Play the years from the newest to the oldest",
+		},
+		&cli.BoolFlag{
+			Name:  "rewind",
+			Usage: "Play the years from the newest to the oldest, marking the frames as rewinding",
+		},
+		&cli.BoolFlag{
+			Name:  "handle-case-normalization",
+			Usage: "Label the frames with the handle in the casing of the user's GitHub profile instead of the one typed",
+		},
+		&cli.IntFlag{
+			Name:    "concurrency",
+			Aliases: []string{"c"},
+			Usage:   "Scrape at most `N` years at once, 1 scrapes them one after the other in the order given, 0 for no limit",
+			Value:   defaults.Concurrency,
+		},
+		&cli.BoolFlag{
+			Name:  "since-join",
+			Usage: "Scrape every year since the user joined GitHub, the years without contributions are empty frames",
+		},
+		&cli.StringFlag{
+			Name:    "out-dir",
+			Aliases: []string{"o"},
+			Usage:   "Save the GIF in the output directory `./dir`, or as the file ./dir/name.gif if it ends in the extension",
+			Value:   defaults.OutDir,
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"O"},
+			Usage:   "Save the GIF as the file `path`, creating its directories, instead of <handle>.gif in the output directory",
+		},
+		&cli.BoolFlag{
+			Name:  "run-folder",
+			Usage: "Save the output of every run in a new timestamped folder inside the output directory",
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Usage:   "Save an animated GIF, an animated WebP, a still PNG per year named <handle>-<year>.png, or only the scraped activity as <handle>.json: `gif|webp|png|json`",
+			Value:   defaults.Format,
+		},
+		&cli.StringFlag{
+			Name:  "json",
+			Usage: "Also write the scraped activity of every year as a JSON array to `FILE`, - for the standard output",
+		},
+		&cli.BoolFlag{
+			Name:  "stdout",
+			Usage: "Write the GIF to standard output instead of the output directory, e.g. to pipe it into gifsicle",
+		},
+		&cli.StringFlag{
+			Name:  "palette",
+			Usage: "Encode the GIF with the `plan9|websafe|adaptive` colors, adaptive picks the most frequent colors of the frames",
+			Value: defaults.Palette,
+		},
+		&cli.StringFlag{
+			Name:  "extension",
+			Usage: "Name the output file with the `gif` extension regardless of its encoding, webp for --format webp",
+			Value: "gif",
+		},
+		&cli.StringFlag{
+			Name:    "delay",
+			Aliases: []string{"d"},
+			Usage:   "Set the transition delay of the GIF to `50`ms",
+			Value:   strconv.Itoa(defaults.Delay),
+		},
+		&cli.IntFlag{
+			Name:  "final-delay",
+			Usage: "Show the final frame for `300` hundredths of a second, in the unit of --delay, instead of --delay",
+		},
+		&cli.BoolFlag{
+			Name:  "title",
+			Usage: "Open the GIF with a frame of the GitHub-username",
+		},
+		&cli.IntFlag{
+			Name:  "title-delay",
+			Usage: "Show the --title frame for `200` hundredths of a second, in the unit of --delay, instead of twice --delay",
+		},
+		&cli.IntFlag{
+			Name:  "loop",
+			Usage: "Play the GIF once with `-1`, loop it forever with 0, or restart it N more times after playing it",
+		},
+		&cli.StringFlag{
+			Name:  "chart",
+			Usage: "Draw every year as a `radar` of the activity overview or as a calendar of the contributions",
+			Value: defaults.Chart,
+		},
+		&cli.Float64Flag{
+			Name:  "fps",
+			Usage: "Set the transition delay from a frame rate of `10` frames per second instead of --delay",
+		},
+		&cli.StringFlag{
+			Name:  "label-pos",
+			Usage: "Draw the handle and year labels at the `bottom`, top or overlay them on the graph",
+			Value: defaults.LabelPos,
+		},
+		&cli.IntFlag{
+			Name:  "live",
+			Usage: "Rebuild latest.gif in the output directory every `N` frames to preview long runs",
+		},
+		&cli.StringFlag{
+			Name:  "frames-dir",
+			Usage: "Also save every frame as <handle>-<frame>.png in the directory `./frames`",
+		},
+		&cli.BoolFlag{
+			Name:  "diff-frames",
+			Usage: "With --frames-dir, also save <handle>-<frame>-diff.png holding only the pixels that changed since the previous frame",
+		},
+		&cli.BoolFlag{
+			Name:  "emit-frames-metadata",
+			Usage: "With --frames-dir, also write frames.json listing the file, year, delay and activity of every frame",
+		},
+		&cli.IntFlag{
+			Name:  "spin",
+			Usage: "Start with `N` frames turning the chart of the first year a full circle",
+		},
+		&cli.StringFlag{
+			Name:  "spin-labels",
+			Usage: "Keep the labels upright while the chart spins, or rotate them with it: `upright|rotate`",
+			Value: defaults.SpinLabels,
+		},
+		&cli.BoolFlag{
+			Name:  "inline-terminal",
+			Usage: "Also display the GIF in terminals supporting inline images, such as iTerm2 and Kitty",
+		},
+		&cli.BoolFlag{
+			Name:  "boomerang",
+			Usage: "Loop back and forth over the last --boomerang-years years, with a 50 delay unless --delay is set",
+		},
+		&cli.BoolFlag{
+			Name:  "bounce",
+			Usage: "Loop back and forth over all the years instead of jumping from the last year to the first",
+		},
+		&cli.IntFlag{
+			Name:  "boomerang-years",
+			Usage: "Number of most recent `years` played by --boomerang",
+			Value: defaults.BoomerangYears,
+		},
+		&cli.BoolFlag{
+			Name:  "autocrop",
+			Usage: "Crop the whitespace around the graphs, keeping the same size for every frame",
+		},
+		&cli.IntFlag{
+			Name:  "padding",
+			Usage: "Surround every frame with a border of `N` pixels, in the background of the graph unless --frame-bg is set",
+		},
+		&cli.StringFlag{
+			Name:  "frame-bg",
+			Usage: "Fill the --padding border with color `#RRGGBB` instead of the background of the graph",
+		},
+		&cli.BoolFlag{
+			Name:  "compact-frames",
+			Usage: "Only encode the region of every frame that changed since the previous one, for smaller GIFs",
+		},
+		&cli.IntFlag{
+			Name:  "repeat-last",
+			Usage: "Append `N` copies of the final frame to pause the animation before looping",
+		},
+		&cli.StringFlag{
+			Name:  "compare-users",
+			Usage: "Overlay the activity of the comma separated `HANDLES` on every frame instead of a single user's",
+		},
+		&cli.StringFlag{
+			Name:  "baseline-year",
+			Usage: "Draw the polygon of `year` faintly underneath the polygon of every year, to show the growth since then",
+		},
+		&cli.StringFlag{
+			Name:  "compare-diff-image",
+			Usage: "Instead of a GIF, save a PNG comparing the activity of years `2016,2020`",
+		},
+		&cli.Int64Flag{
+			Name:  "max-response-bytes",
+			Usage: "Fail any scrape whose response body is larger than `N` bytes",
+			Value: defaults.MaxResponseBytes,
+		},
+		&cli.BoolFlag{
+			Name:  "smooth",
+			Usage: "Draw the activity as a smooth curve through the vertices instead of a polygon",
+		},
+		&cli.BoolFlag{
+			Name:  "dither-fill",
+			Usage: "Dither the translucent polygons of --compare-users and --baseline-year, which reads better in the GIF palette than blending",
+		},
+		&cli.BoolFlag{
+			Name:  "scale-ticks",
+			Usage: "Mark where 25, 50, 75 and 100% fall along the code review axis",
+		},
+		&cli.BoolFlag{
+			Name:  "legend",
+			Usage: "List every metric with its value in the top right corner, instead of along the axes where a large polygon covers them",
+		},
+		&cli.BoolFlag{
+			Name:  "grid",
+			Usage: "Draw faint rings through 25, 50, 75 and 100% of every axis, underneath the polygon",
+		},
+		&cli.StringFlag{
+			Name:  "label-template",
+			Usage: "Caption every frame with `TEMPLATE`, e.g. \"{handle} ({commits}% commits)\\n{year}\", placeholders: {handle}, {year}, {commits}, {issues}, {prs}, {codeReviews}, {streak}",
+		},
+		&cli.BoolFlag{
+			Name:  "no-labels",
+			Usage: "Only draw the polygon, axes and markers, without any text",
+		},
+		&cli.BoolFlag{
+			Name:  "show-streak",
+			Usage: "Scrape the contributions calendar and display the longest streak of the year",
+		},
+		&cli.BoolFlag{
+			Name:  "deltas",
+			Usage: "Draw the change of every metric since the previous year next to its value",
+		},
+		&cli.StringFlag{
+			Name:  "bg-gradient",
+			Usage: "Fill the background with a vertical gradient from top to bottom colors `#fff:#eee`",
+		},
+		&cli.BoolFlag{
+			Name:  "transparent",
+			Usage: "Leave the background transparent instead of white, to overlay the GIF on colored pages",
+		},
+		&cli.StringFlag{
+			Name:  "font",
+			Usage: "Draw the labels and values in the font of the `file.ttf` instead of Go Regular",
+		},
+		&cli.Float64Flag{
+			Name:  "label-size",
+			Usage: "Draw the labels in `points`, instead of 24 points scaled with the --size of the canvas",
+		},
+		&cli.Float64Flag{
+			Name:  "value-size",
+			Usage: "Draw the values in `points`, instead of 22 points scaled with the --size of the canvas",
+		},
+		&cli.StringFlag{
+			Name:  "origin-dot",
+			Usage: "Mark the origin of the axes with a dot of color `#RRGGBB`",
+		},
+		&cli.StringFlag{
+			Name:  "axis-color",
+			Usage: "Draw the axes and markers in color `#RRGGBB`",
+		},
+		&cli.StringFlag{
+			Name:  "poly-color",
+			Usage: "Fill the polygon with color `#RRGGBB`",
+		},
+		&cli.StringFlag{
+			Name:  "label-color",
+			Usage: "Write the axis labels, handle and year in color `#RRGGBB`",
+		},
+		&cli.StringFlag{
+			Name:  "value-color",
+			Usage: "Write the percentages in color `#RRGGBB`",
+		},
+		&cli.BoolFlag{
+			Name:  "theme-from-profile",
+			Usage: "Draw the polygon and axes in the dominant color of the user's avatar",
+		},
+		&cli.DurationFlag{
+			Name:  "render-timeout",
+			Usage: "Abort if rendering the frames and encoding the output take longer than `duration` once the scraping is over, 0 to not bound them",
+		},
+		&cli.IntFlag{
+			Name:    "size",
+			Aliases: []string{"s"},
+			Usage:   "Draw the radar chart `width` pixels wide, the height keeps the 500x560 aspect ratio",
+			Value:   defaults.Size,
+		},
+		&cli.Float64Flag{
+			Name:  "min-delta",
+			Usage: "Draw every non-zero metric at least `fraction` of the axis length away from the origin, so tiny values stay visible",
+		},
+		&cli.StringFlag{
+			Name:  "axis-extent",
+			Usage: "End the axes at the full axis length, at the vertex of the polygon or at the edges of the graph: `full|to-vertex|edge`",
+			Value: defaults.AxisExtent,
+		},
+		&cli.BoolFlag{
+			Name:  "size-by-total",
+			Usage: "Scale the polygon of every year and user by its total contributions, the largest total is drawn full size, requires --backend graphql",
+		},
+		&cli.BoolFlag{
+			Name:  "highlight-max",
+			Usage: "Draw the axis, marker and label of the largest metric of every year in an accent color",
+		},
+		&cli.BoolFlag{
+			Name:  "merge-years",
+			Usage: "Average the activity of all the scraped years into a single frame",
+		},
+		&cli.BoolFlag{
+			Name:    "report",
+			Aliases: []string{"summary-only"},
+			Usage:   "Print a summary of the activity of every year with a bar per metric, instead of creating any image",
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print a table of the scraped activity of every year, instead of creating any image",
+		},
+		&cli.BoolFlag{
+			Name:    "quiet",
+			Aliases: []string{"q"},
+			Usage:   "Do not print the progress nor the log lines of every year, only the errors that stop the run",
+		},
+		&cli.BoolFlag{
+			Name:  "dump-coords",
+			Usage: "Log the computed coordinates of every graph, to debug layout issues",
+		},
+		&cli.StringFlag{
+			Name:  "metrics-file",
+			Usage: "After the run, write counters of requests, scrapes and durations to `metrics.prom` in Prometheus text format",
+		},
+		&cli.BoolFlag{
+			Name:  "embed-source",
+			Usage: "Write the scraped URLs in a comment of the GIF, for provenance",
+		},
+		&cli.BoolFlag{
+			Name:  "validate-output",
+			Usage: "Decode the GIF once created and check its frames, removing it if it is corrupt",
+		},
+		&cli.BoolFlag{
+			Name:  "sidecar",
+			Usage: "Write the generation metadata of the GIF, such as the options and activities, to <gif>.json next to it",
+		},
+		&cli.BoolFlag{
+			Name:  "reproducible",
+			Usage: "Reject the options whose output differs between runs on the same activity",
+		},
+		&cli.BoolFlag{
+			Name:  "sample",
+			Usage: "Create sample.gif from built-in activity, without a GitHub-username nor network access",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "Fail instead of leaving out the years whose activity could not be scraped",
+		},
+		&cli.BoolFlag{
+			Name:  "strict-markup",
+			Usage: "Fail instead of falling back when the scraped markup deviates from the expected one",
+		},
+		&cli.StringFlag{
+			Name:    "token",
+			Aliases: []string{"t"},
+			EnvVars: []string{"GIFHUB_TOKEN"},
+			Usage:   "Authenticate the requests with a GitHub personal access `token`",
+		},
+		&cli.StringFlag{
+			Name:  "host",
+			Usage: "Scrape the GitHub Enterprise instance at `host`, such as github.example.com, instead of GitHub",
+			Value: defaults.Host,
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Scrape every year again instead of reusing the activity cached by previous runs",
+		},
+		&cli.DurationFlag{
+			Name:  "cache-ttl",
+			Usage: "Scrape the current year again once its cached activity is older than `duration`, past years are cached indefinitely",
+			Value: defaults.CacheTTL,
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Usage: "Retry a request failing with a network error, a 429 or a 5xx status up to `N` times, with exponential backoff",
+			Value: defaults.Retries,
+		},
+		&cli.IntFlag{
+			Name:  "timeout",
+			Usage: "Fail a request to GitHub that takes longer than `seconds`, 0 to wait indefinitely",
+			Value: int(defaults.Timeout / time.Second),
+		},
+		&cli.BoolFlag{
+			Name:  "no-keepalive",
+			Usage: "Open a new connection for every request instead of reusing them, for debugging",
+		},
+		&cli.StringFlag{
+			Name:  "backend",
+			Usage: "Scrape the activity overview from the profile `html`, or count the contributions with the GraphQL API, which requires --token: html|graphql",
+			Value: defaults.Backend,
+		},
+		&cli.StringFlag{
+			Name:  "markup-version",
+			Usage: "Scrape the activity with the strategy of markup `version` 2023 or 2024, auto tries them in order",
+			Value: defaults.MarkupVersion,
+		},
+		&cli.BoolFlag{
+			Name:  "self-check",
+			Usage: "Verify scraping still works against the last year of a well-known profile, or of the given GitHub-username",
+		},
+	}
+	app.Action = generate
+	app.Commands = []*cli.Command{
+		{
+			Name:      "watch",
+			Usage:     "Regenerate the GIF on a schedule until interrupted",
+			ArgsUsage: "GitHub-username",
+			Flags: append([]cli.Flag{
+				&cli.DurationFlag{
+					Name:  "interval",
+					Usage: "Regenerate the GIF every `duration`",
+					Value: 24 * time.Hour,
+				},
+			}, app.Flags...),
+			Action: watch,
+		},
+	}
+
+	app.CustomAppHelpTemplate = `NAME:
+	 {{.Name}} - {{.Usage}}
+
+USAGE:
+   {{.HelpName}} {{if .VisibleFlags}}[global options]{{end}} GitHub-username [GitHub-username]
+   {{.HelpName}} watch [options] GitHub-username
+
+COMMANDS:
+{{range .VisibleCommands}}   {{.Name}}{{"\t"}}{{.Usage}}
+{{end}}
+GLOBAL OPTIONS:{{if .VisibleFlags}}
+{{range .VisibleFlags}}{{.}}
+{{end}}{{end}}
+`
+	return app
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CamiloGarciaLaRotta/gifhub"
+	"github.com/urfave/cli/v2"
+)
+
+// githubLaunch is the year GitHub launched, which no activity predates
+const githubLaunch = 2008
+
+// boomerangDelay is the transition delay of --boomerang animations
+const boomerangDelay = 50
+
+// generate creates the GIF of the GitHub-usernames passed as arguments
+func generate(c *cli.Context) error {
+	if !c.Bool("sample") && !c.IsSet("compare-users") && (c.NArg() > 2 || c.NArg() == 0 && !c.Bool("self-check")) {
+		return cli.ShowAppHelp(c)
+	}
+	opts, err := options(c)
+	if err != nil {
+		return err
+	}
+	return gifhub.Generate(c.Context, opts)
+}
+
+// options returns the options of gifhub.Generate set by the flags and arguments
+// the flags that only conflict when typed, whatever their value, are rejected here
+func options(c *cli.Context) (gifhub.GenerateOptions, error) {
+	o := gifhub.GenerateOptions{
+		Sample:                  c.Bool("sample"),
+		Years:                   c.String("years"),
+		SinceJoin:               c.Bool("since-join"),
+		Reverse:                 c.Bool("reverse"),
+		Rewind:                  c.Bool("rewind"),
+		HandleCaseNormalization: c.Bool("handle-case-normalization"),
+		Concurrency:             c.Int("concurrency"),
+		MergeYears:              c.Bool("merge-years"),
+		OutDir:                  c.String("out-dir"),
+		Output:                  c.String("output"),
+		RunFolder:               c.Bool("run-folder"),
+		Format:                  c.String("format"),
+		JSON:                    c.String("json"),
+		Stdout:                  c.Bool("stdout"),
+		Palette:                 c.String("palette"),
+		Delay:                   c.Int("delay"),
+		FPS:                     c.Float64("fps"),
+		FinalDelay:              c.Int("final-delay"),
+		Title:                   c.Bool("title"),
+		TitleDelay:              c.Int("title-delay"),
+		Loop:                    c.Int("loop"),
+		Live:                    c.Int("live"),
+		FramesDir:               c.String("frames-dir"),
+		DiffFrames:              c.Bool("diff-frames"),
+		EmitFramesMetadata:      c.Bool("emit-frames-metadata"),
+		Spin:                    c.Int("spin"),
+		SpinLabels:              c.String("spin-labels"),
+		InlineTerminal:          c.Bool("inline-terminal"),
+		Boomerang:               c.Bool("boomerang"),
+		BoomerangYears:          c.Int("boomerang-years"),
+		Bounce:                  c.Bool("bounce"),
+		Autocrop:                c.Bool("autocrop"),
+		Padding:                 c.Int("padding"),
+		FrameBg:                 c.String("frame-bg"),
+		CompactFrames:           c.Bool("compact-frames"),
+		RepeatLast:              c.Int("repeat-last"),
+		BaselineYear:            c.String("baseline-year"),
+		CompareDiffImage:        c.String("compare-diff-image"),
+		MaxResponseBytes:        c.Int64("max-response-bytes"),
+		Chart:                   c.String("chart"),
+		LabelPos:                c.String("label-pos"),
+		LabelTemplate:           c.String("label-template"),
+		Smooth:                  c.Bool("smooth"),
+		DitherFill:              c.Bool("dither-fill"),
+		ScaleTicks:              c.Bool("scale-ticks"),
+		Legend:                  c.Bool("legend"),
+		Grid:                    c.Bool("grid"),
+		NoLabels:                c.Bool("no-labels"),
+		ShowStreak:              c.Bool("show-streak"),
+		Deltas:                  c.Bool("deltas"),
+		BgGradient:              c.String("bg-gradient"),
+		Transparent:             c.Bool("transparent"),
+		Font:                    c.String("font"),
+		LabelSize:               c.Float64("label-size"),
+		ValueSize:               c.Float64("value-size"),
+		OriginDot:               c.String("origin-dot"),
+		AxisColor:               c.String("axis-color"),
+		PolyColor:               c.String("poly-color"),
+		LabelColor:              c.String("label-color"),
+		ValueColor:              c.String("value-color"),
+		ThemeFromProfile:        c.Bool("theme-from-profile"),
+		RenderTimeout:           c.Duration("render-timeout"),
+		Size:                    c.Int("size"),
+		MinDelta:                c.Float64("min-delta"),
+		AxisExtent:              c.String("axis-extent"),
+		SizeByTotal:             c.Bool("size-by-total"),
+		HighlightMax:            c.Bool("highlight-max"),
+		Report:                  c.Bool("report"),
+		DryRun:                  c.Bool("dry-run"),
+		Quiet:                   c.Bool("quiet"),
+		DumpCoords:              c.Bool("dump-coords"),
+		MetricsFile:             c.String("metrics-file"),
+		EmbedSource:             c.Bool("embed-source"),
+		ValidateOutput:          c.Bool("validate-output"),
+		Sidecar:                 c.Bool("sidecar"),
+		SidecarOptions:          resolvedOptions(c),
+		Reproducible:            c.Bool("reproducible"),
+		Strict:                  c.Bool("strict"),
+		StrictMarkup:            c.Bool("strict-markup"),
+		SelfCheck:               c.Bool("self-check"),
+		Token:                   c.String("token"),
+		Host:                    c.String("host"),
+		NoCache:                 c.Bool("no-cache"),
+		CacheTTL:                c.Duration("cache-ttl"),
+		Retries:                 c.Int("retries"),
+		Timeout:                 time.Duration(c.Int("timeout")) * time.Second,
+		NoKeepAlive:             c.Bool("no-keepalive"),
+		Backend:                 c.String("backend"),
+		MarkupVersion:           c.String("markup-version"),
+	}
+	if c.IsSet("extension") {
+		o.Extension = c.String("extension")
+	}
+
+	switch {
+	case o.Sample && c.IsSet("compare-users"):
+		return o, errors.New("--sample and --compare-users are mutually exclusive")
+	case o.Sample:
+	case c.IsSet("compare-users"):
+		if c.NArg() > 0 {
+			return o, errors.New("--compare-users replaces the GitHub-username argument")
+		}
+		raw := c.String("compare-users")
+		if o.Handles = strings.Split(raw, ","); len(o.Handles) < 2 {
+			return o, fmt.Errorf("compare users: expected at least two handles, got %q", raw)
+		}
+	default:
+		// a second user is compared side by side, as with --compare-users
+		o.Handles = c.Args().Slice()
+	}
+
+	// the years are validated before the handles are looked up
+	if !o.Sample {
+		if err := checkYearFlags(c.IsSet("years"), c.IsSet("years-from"), o.SinceJoin); err != nil {
+			return o, err
+		}
+		if c.IsSet("years-from") {
+			years, err := yearsFrom(c.Int("years-from"), time.Now().Year())
+			if err != nil {
+				return o, err
+			}
+			o.Years = years
+		}
+	}
+	if o.Reproducible && c.IsSet("years-from") {
+		// its range of years ends at the current one, which changes with the time of the run
+		return o, errors.New("--reproducible and --years-from are mutually exclusive, the years run through the current one")
+	}
+
+	switch {
+	case c.IsSet("fps") && c.IsSet("delay"):
+		return o, errors.New("--delay and --fps are mutually exclusive")
+	case o.Boomerang && !c.IsSet("delay") && !c.IsSet("fps"):
+		o.Delay = boomerangDelay
+	}
+	// the palette defaults to plan9, only a typed --palette is rejected for a WebP
+	if o.Format == "webp" && c.IsSet("palette") {
+		return o, errors.New("--palette only applies to GIFs, not to --format webp")
+	}
+	return o, nil
+}
+
+// checkYearFlags rejects the mutually exclusive flags of the years: whether --years, --years-from and --since-join are set
+func checkYearFlags(years, yearsFrom, sinceJoin bool) error {
+	switch {
+	case years && yearsFrom:
+		return errors.New("--years and --years-from are mutually exclusive")
+	case sinceJoin && (years || yearsFrom):
+		return errors.New("--since-join replaces --years and --years-from")
+	}
+	return nil
+}
+
+// yearsFrom returns the range of years of --years-from, from the year from through now
+func yearsFrom(from, now int) (string, error) {
+	if from < githubLaunch || from > now {
+		return "", fmt.Errorf("invalid years from %d, must be between %d and %d", from, githubLaunch, now)
+	}
+	return fmt.Sprintf("%d-%d", from, now), nil
+}
+
+// resolvedOptions returns the value of every flag of the app, including the defaults, and the GitHub-usernames
+// passed as arguments under handles. The token is redacted
+func resolvedOptions(c *cli.Context) map[string]interface{} {
+	options := map[string]interface{}{}
+	for _, f := range c.App.Flags {
+		name := f.Names()[0]
+		switch {
+		case name == "help":
+			continue
+		case name == "token" && c.String(name) != "":
+			options[name] = "REDACTED"
+		default:
+			options[name] = c.String(name)
+		}
+	}
+	if c.NArg() > 0 {
+		options["handles"] = c.Args().Slice()
+	}
+	return options
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CamiloGarciaLaRotta/gifhub"
+	"github.com/urfave/cli/v2"
+)
+
+// parse returns the options of the command line args, without generating the GIF
+func parse(t *testing.T, args ...string) (gifhub.GenerateOptions, error) {
+	t.Helper()
+	var o gifhub.GenerateOptions
+	var err error
+	app := newApp()
+	app.Action = func(c *cli.Context) error {
+		o, err = options(c)
+		return nil
+	}
+	if runErr := app.Run(append([]string{"gifhub"}, args...)); runErr != nil {
+		t.Fatal(runErr)
+	}
+	return o, err
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	o, err := parse(t, "octocat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := gifhub.DefaultGenerateOptions()
+	want.Handles = []string{"octocat"}
+	want.SidecarOptions = o.SidecarOptions
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("expected the default options\n%+v\ngot\n%+v", want, o)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	now := strconv.Itoa(time.Now().Year())
+	for _, tc := range []struct {
+		args []string
+		ok   func(o gifhub.GenerateOptions) bool
+	}{
+		{[]string{"octocat", "monalisa"}, func(o gifhub.GenerateOptions) bool {
+			return reflect.DeepEqual(o.Handles, []string{"octocat", "monalisa"})
+		}},
+		{[]string{"--compare-users", "octocat,monalisa"}, func(o gifhub.GenerateOptions) bool {
+			return reflect.DeepEqual(o.Handles, []string{"octocat", "monalisa"})
+		}},
+		{[]string{"--sample", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Sample && o.Handles == nil }},
+		{[]string{"--years-from", "2019", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Years == "2019-"+now }},
+		{[]string{"--boomerang", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Delay == boomerangDelay }},
+		{[]string{"--boomerang", "--delay", "30", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Delay == 30 }},
+		{[]string{"--boomerang", "--fps", "20", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.FPS == 20 }},
+		{[]string{"--format", "webp", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Extension == "" }},
+		{[]string{"--format", "webp", "--extension", "gif", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Extension == "gif" }},
+		{[]string{"--timeout", "5", "octocat"}, func(o gifhub.GenerateOptions) bool { return o.Timeout == 5*time.Second }},
+	} {
+		o, err := parse(t, tc.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tc.args, err)
+			continue
+		}
+		if !tc.ok(o) {
+			t.Errorf("%v: unexpected options %+v", tc.args, o)
+		}
+	}
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--sample", "--compare-users", "octocat,monalisa"}, "--sample and --compare-users"},
+		{[]string{"--compare-users", "octocat,monalisa", "hubot"}, "--compare-users replaces"},
+		{[]string{"--compare-users", "octocat"}, "expected at least two handles"},
+		{[]string{"--years", "2020", "--years-from", "2019", "octocat"}, "--years and --years-from"},
+		{[]string{"--since-join", "--years", "2020", "octocat"}, "--since-join replaces"},
+		{[]string{"--years-from", "2000", "octocat"}, "invalid years from"},
+		{[]string{"--reproducible", "--years-from", "2019", "octocat"}, "--reproducible and --years-from"},
+		{[]string{"--delay", "30", "--fps", "10", "octocat"}, "--delay and --fps"},
+		{[]string{"--format", "webp", "--palette", "plan9", "octocat"}, "--palette only applies to GIFs"},
+	} {
+		if _, err := parse(t, tc.args...); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%v: expected %q, got %v", tc.args, tc.err, err)
+		}
+	}
+}
+
+func TestCheckYearFlags(t *testing.T) {
+	for _, tc := range []struct {
+		years, yearsFrom, sinceJoin bool
+		err                         string
+	}{
+		{false, false, false, ""},
+		{true, false, false, ""},
+		{false, true, false, ""},
+		{false, false, true, ""},
+		{true, true, false, "mutually exclusive"},
+		{true, false, true, "--since-join replaces"},
+		{false, true, true, "--since-join replaces"},
+	} {
+		err := checkYearFlags(tc.years, tc.yearsFrom, tc.sinceJoin)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%+v: %v", tc, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%+v: expected %q, got %v", tc, tc.err, err)
+		}
+	}
+}
+
+func TestYearsFrom(t *testing.T) {
+	for _, from := range []int{0, -1, githubLaunch - 1, 2021} {
+		if _, err := yearsFrom(from, 2020); err == nil || !strings.Contains(err.Error(), "invalid years from") {
+			t.Errorf("%d: expected an invalid years from error, got %v", from, err)
+		}
+	}
+	if years, err := yearsFrom(2019, 2020); err != nil || years != "2019-2020" {
+		t.Errorf("expected 2019-2020, got %q %v", years, err)
+	}
+}
+
+func TestResolvedOptions(t *testing.T) {
+	var got map[string]interface{}
+	app := &cli.App{
+		Flags: []cli.Flag{
+			&cli.IntFlag{Name: "delay", Value: 100},
+			&cli.StringFlag{Name: "palette", Value: "plan9"},
+			&cli.BoolFlag{Name: "sample"},
+		},
+		Action: func(c *cli.Context) error {
+			got = resolvedOptions(c)
+			return nil
+		},
+	}
+	if err := app.Run([]string{"gifhub", "--delay", "40", "octocat", "monalisa"}); err != nil {
+		t.Fatal(err)
+	}
+	// both users compared side by side are recorded
+	want := map[string]interface{}{"delay": "40", "palette": "plan9", "sample": "false", "handles": []string{"octocat", "monalisa"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the options %v, got %v", want, got)
+	}
+
+	if err := app.Run([]string{"gifhub", "--sample"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["handles"]; ok {
+		t.Errorf("expected no handles without arguments, got %v", got["handles"])
+	}
+}
+
+func TestSidecarRedactsToken(t *testing.T) {
+	for token, want := range map[string]string{"ghp_secret": "REDACTED", "": ""} {
+		app := &cli.App{
+			Flags: []cli.Flag{&cli.StringFlag{Name: "token"}},
+			Action: func(c *cli.Context) error {
+				if got := resolvedOptions(c)["token"]; got != want {
+					t.Errorf("token %q: expected %q, got %q", token, want, got)
+				}
+				return nil
+			},
+		}
+		if err := app.Run([]string{"gifhub", "--token", token}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// watch regenerates the GIF every interval until interrupted
+// failed runs are logged and retried on the next tick, as they are usually transient
+func watch(c *cli.Context) error {
+	interval := c.Duration("interval")
+	if interval <= 0 {
+		return errors.New("invalid interval, must be positive")
+	}
+	if c.NArg() != 1 && !c.Bool("sample") {
+		return cli.ShowSubcommandHelp(c)
+	}
+
+	// a first interrupt stops after the ongoing run, a second one kills the process as usual
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	stop := make(chan struct{})
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		log.Println("Watch: interrupted, stopping after the ongoing run")
+		close(stop)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	runs := schedule(ticker.C, stop, func() error {
+		return generate(c)
+	})
+	log.Printf("Watch: stopped after %d runs\n", runs)
+	return nil
+}
+
+// schedule runs generate once right away and then on every tick until stop is closed
+// it returns the number of runs
+func schedule(ticks <-chan time.Time, stop <-chan struct{}, generate func() error) int {
+	runs := 0
+	for {
+		runs++
+		log.Printf("Watch: run %d\n", runs)
+		if err := generate(); err != nil {
+			log.Printf("Watch: run %d: %v\n", runs, err)
+		}
+
+		// a tick may be pending as well when the run outlasted the interval, stopping comes first
+		select {
+		case <-stop:
+			return runs
+		default:
+		}
+		select {
+		case <-stop:
+			return runs
+		case <-ticks:
+		}
+	}
+}
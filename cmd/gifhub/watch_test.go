@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestSchedule(t *testing.T) {
+	var logs bytes.Buffer
+	out := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(out)
+
+	// the ticks of a fake clock, sent by the test
+	ticks := make(chan time.Time)
+	stop := make(chan struct{})
+	calls := 0
+	done := make(chan int)
+	go func() {
+		done <- schedule(ticks, stop, func() error {
+			calls++
+			switch calls {
+			case 2:
+				return errors.New("rate limited")
+			case 4:
+				close(stop)
+			}
+			return nil
+		})
+	}()
+
+	// a run right away, then one per tick
+	for i := 0; i < 3; i++ {
+		select {
+		case ticks <- time.Now():
+		case <-time.After(time.Second):
+			t.Fatalf("expected tick %d to be received", i+1)
+		}
+	}
+	select {
+	case runs := <-done:
+		if runs != 4 || calls != 4 {
+			t.Errorf("expected 4 runs for 3 ticks, got %d runs and %d calls", runs, calls)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the schedule to stop")
+	}
+	if !strings.Contains(logs.String(), "Watch: run 2: rate limited") {
+		t.Errorf("expected the failed run logged, got %q", logs.String())
+	}
+}
+
+func TestScheduleStopsFirst(t *testing.T) {
+	// a tick is pending once the run is over, stopping takes precedence
+	ticks := make(chan time.Time, 1)
+	stop := make(chan struct{})
+	runs := schedule(ticks, stop, func() error {
+		ticks <- time.Now()
+		close(stop)
+		return nil
+	})
+	if runs != 1 {
+		t.Errorf("expected a single run, got %d", runs)
+	}
+}
+
+func TestWatchInterval(t *testing.T) {
+	app := &cli.App{
+		Flags:  []cli.Flag{&cli.DurationFlag{Name: "interval"}, &cli.BoolFlag{Name: "sample"}},
+		Action: watch,
+	}
+	err := app.Run([]string{"gifhub", "--interval", "0s", "octocat"})
+	if err == nil || !strings.Contains(err.Error(), "invalid interval") {
+		t.Errorf("expected an invalid interval, got %v", err)
+	}
+}
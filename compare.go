@@ -0,0 +1,186 @@
+package gifhub
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"strings"
+
+	"github.com/fogleman/gg"
+)
+
+// series is the activity of one of the users overlaid on a graph
+type series struct {
+	Data   activity
+	Coords coords
+	Color  color.Color
+}
+
+// seriesColors are the colors of the overlaid users, in the order they are passed to --compare-users
+var seriesColors = []color.Color{
+	color.RGBA{123, 201, 111, 0xff},
+	color.RGBA{3, 102, 214, 0xff},
+	color.RGBA{227, 98, 9, 0xff},
+	color.RGBA{111, 66, 193, 0xff},
+	color.RGBA{215, 58, 73, 0xff},
+}
+
+// parseHandles returns the handles passed to the --compare-users flag
+func parseHandles(rawFlag string) ([]string, error) {
+	handles := []string{}
+	for _, handle := range strings.Split(rawFlag, ",") {
+		if handle = strings.TrimSpace(handle); handle == "" {
+			continue
+		}
+		if contains(handles, handle) {
+			return nil, fmt.Errorf("duplicate handle %q", handle)
+		}
+		handles = append(handles, handle)
+	}
+	switch {
+	case len(handles) < 2:
+		return nil, fmt.Errorf("expected at least two handles, got %q", rawFlag)
+	case len(handles) > len(seriesColors):
+		return nil, fmt.Errorf("expected at most %d handles, got %d", len(seriesColors), len(handles))
+	}
+	return handles, nil
+}
+
+// overlayGraphs passes a graph into a channel for every year found in the input channels,
+// overlaying the activity of every user on that year, the i-th channel is drawn in seriesColors[i]
+// users without an activity for a year are left out of its graph
+// with l.SizeByTotal, every polygon is scaled by the largest total of all the users and years
+func overlayGraphs(in []<-chan activity, handle string, size int, l layout) <-chan graph {
+	var out = make(chan graph, size)
+	go func() {
+		defer close(out)
+		byYear := map[string][]series{}
+		acts := []activity{}
+		for i, actc := range in {
+			for act := range actc {
+				byYear[act.Year] = append(byYear[act.Year], series{act, coords{}, seriesColors[i]})
+				acts = append(acts, act)
+			}
+		}
+		scaled := l
+		if l.SizeByTotal {
+			scaled.MaxTotal = maxTotal(acts)
+		}
+		for _, srs := range byYear {
+			for i := range srs {
+				srs[i].Coords = coordinates(srs[i].Data, scaled)
+			}
+		}
+
+		years := make([]string, 0, len(byYear))
+		for year := range byYear {
+			years = append(years, year)
+		}
+		sort.Strings(years)
+		for _, year := range years {
+			act := activity{Handle: handle, Year: year}
+			out <- graph{Data: act, Coords: coordinates(act, l), Series: byYear[year]}
+		}
+	}()
+	return out
+}
+
+// seriesFillAlpha is the opacity of the fill of the overlaid polygons
+const seriesFillAlpha = 0x50
+
+// bayer4 is the threshold map of a 4x4 ordered dither
+var bayer4 = [4][4]float64{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ditherPattern fills a share alpha of the pixels with an opaque color, spread in an ordered dither
+// it approximates a translucent fill with the colors of the GIF palette, which blends translucent colors poorly
+type ditherPattern struct {
+	Color color.Color
+	Alpha float64 // between 0 and 1
+}
+
+// ColorAt implements gg.Pattern
+func (p ditherPattern) ColorAt(x, y int) color.Color {
+	if (bayer4[y&3][x&3]+0.5)/16 < p.Alpha {
+		return p.Color
+	}
+	return color.Transparent
+}
+
+// drawSeries draws the polygon and markers of every series in its own color
+// with s.DitherFill the translucent fill is dithered instead of blended
+func drawSeries(series []series, s style, dc *gg.Context) {
+	for _, sr := range series {
+		if s.DitherFill {
+			dc.SetFillStyle(ditherPattern{sr.Color, seriesFillAlpha / 255.0})
+		} else {
+			r, g, b, _ := sr.Color.RGBA()
+			dc.SetColor(color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), seriesFillAlpha})
+		}
+		if s.Smooth {
+			spline(sr.Coords, dc)
+		} else {
+			polygon(sr.Coords, dc)
+		}
+		dc.FillPreserve()
+		dc.SetColor(sr.Color)
+		dc.SetLineWidth(sr.Coords.Factor * 0.08)
+		dc.Stroke()
+	}
+}
+
+// drawSeriesMarkers draws the markers of the non-zero metrics of every series in its own color
+func drawSeriesMarkers(series []series, s style, dc *gg.Context) {
+	for _, sr := range series {
+		c := sr.Coords
+		if sr.Data.CodeReviews > 0 {
+			circle(sr.Color, color.White, s.MarkerRadius, c.Mid, c.CodeReviewY, dc)
+		}
+		if sr.Data.Issues > 0 {
+			circle(sr.Color, color.White, s.MarkerRadius, c.IssuesX, c.MidY, dc)
+		}
+		if sr.Data.Prs > 0 {
+			circle(sr.Color, color.White, s.MarkerRadius, c.Mid, c.PrsY, dc)
+		}
+		if sr.Data.Commits > 0 {
+			circle(sr.Color, color.White, s.MarkerRadius, c.CommitsX, c.MidY, dc)
+		}
+	}
+}
+
+// drawSeriesLegend draws the handle of every series next to a swatch of its color
+// in the bottom left corner of a canvas of height h
+func drawSeriesLegend(series []series, h, factor float64, s style, dc *gg.Context) {
+	dc.SetFontFace(s.ValueFont)
+	top := h - float64(len(series))*0.4*factor - 0.2*factor
+	for i, sr := range series {
+		y := top + float64(i)*0.4*factor
+		dc.SetColor(sr.Color)
+		dc.DrawRectangle(0.2*factor, y-0.12*factor, 0.24*factor, 0.24*factor)
+		dc.Fill()
+		dc.SetColor(s.ValueColor)
+		dc.DrawStringAnchored(sr.Data.Handle, 0.6*factor, y, 0, 0.5)
+	}
+}
+
+// unionYears returns the years passed to the -y flag for every user,
+// with all years this is every year any of the users was active in
+func unionYears(rawFlag string, handles []string, opts fetchOptions) ([]string, error) {
+	years := []string{}
+	for _, handle := range handles {
+		userYears, err := parseYearFlag(rawFlag, handle, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, year := range userYears {
+			if !contains(years, year) {
+				years = append(years, year)
+			}
+		}
+	}
+	return years, nil
+}
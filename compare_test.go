@@ -0,0 +1,119 @@
+package gifhub
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/fogleman/gg"
+)
+
+func TestDitherPattern(t *testing.T) {
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	for _, alpha := range []float64{0, 0.25, seriesFillAlpha / 255.0, 0.5, 1} {
+		p := ditherPattern{red, alpha}
+		opaque := 0
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				c := p.ColorAt(x, y)
+				switch c {
+				case red:
+					opaque++
+				case color.Transparent:
+				default:
+					t.Fatalf("alpha %g: expected an opaque or transparent pixel, got %v", alpha, c)
+				}
+				// the pattern repeats every 4 pixels
+				if p.ColorAt(x+4, y+8) != c {
+					t.Errorf("alpha %g: expected the pattern to repeat at %d,%d", alpha, x+4, y+8)
+				}
+			}
+		}
+		if want := int(math.Round(alpha * 16)); opaque != want {
+			t.Errorf("alpha %g: expected %d of 16 pixels filled, got %d", alpha, want, opaque)
+		}
+	}
+
+	// half of the pixels are filled in a checkerboard, no two neighbours alike
+	half := ditherPattern{red, 0.5}
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 3; x++ {
+			if half.ColorAt(x, y) == half.ColorAt(x+1, y) {
+				t.Errorf("expected the pixels %d,%d and %d,%d to differ", x, y, x+1, y)
+			}
+		}
+	}
+}
+
+func TestDrawSeriesDither(t *testing.T) {
+	act := activity{Commits: 50, Issues: 50, Prs: 50, CodeReviews: 50}
+	c := coordinates(act, layout{Width: defaultWidth})
+	sr := []series{{Data: act, Coords: c, Color: seriesColors[0]}}
+	draw := func(dither bool) image.Image {
+		dc := gg.NewContext(int(c.W), int(c.H))
+		dc.SetColor(color.White)
+		dc.Clear()
+		drawSeries(sr, style{DitherFill: dither}, dc)
+		return dc.Image()
+	}
+
+	// a block inside the polygon, away from its outline
+	inside := image.Rect(int(c.Mid)+10, int(c.Mid)+10, int(c.Mid)+26, int(c.Mid)+26)
+	white, solid := color.RGBAModel.Convert(color.White), color.RGBAModel.Convert(seriesColors[0])
+	count := func(m image.Image) (whites, solids, blends int) {
+		for y := inside.Min.Y; y < inside.Max.Y; y++ {
+			for x := inside.Min.X; x < inside.Max.X; x++ {
+				switch color.RGBAModel.Convert(m.At(x, y)) {
+				case white:
+					whites++
+				case solid:
+					solids++
+				default:
+					blends++
+				}
+			}
+		}
+		return
+	}
+
+	whites, solids, blends := count(draw(true))
+	if blends != 0 {
+		t.Errorf("expected the dithered fill to only hold the background and the series color, got %d blended pixels", blends)
+	}
+	if want := int(math.Round(seriesFillAlpha/255.0*16)) * 16; solids != want || whites != 256-want {
+		t.Errorf("expected %d of 256 pixels filled, got %d filled and %d background", want, solids, whites)
+	}
+	if _, _, blends := count(draw(false)); blends != 256 {
+		t.Errorf("expected the blended fill everywhere, got %d of 256 blended pixels", blends)
+	}
+}
+
+func TestParseHandles(t *testing.T) {
+	for _, tc := range []struct {
+		raw, handles, err string
+	}{
+		{"octocat,hubot", "[octocat hubot]", ""},
+		{" octocat , hubot ,", "[octocat hubot]", ""},
+		{"octocat,octocat", "", `duplicate handle "octocat"`},
+		{"octocat", "", "expected at least two handles"},
+	} {
+		handles, err := parseHandles(tc.raw)
+		switch {
+		case tc.err == "" && (err != nil || fmt.Sprint(handles) != tc.handles):
+			t.Errorf("%q: expected %s, got %v %v", tc.raw, tc.handles, handles, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%q: expected %q, got %v", tc.raw, tc.err, err)
+		}
+	}
+
+	many := []string{}
+	for i := 0; i <= len(seriesColors); i++ {
+		many = append(many, fmt.Sprintf("user%d", i))
+	}
+	if _, err := parseHandles(strings.Join(many, ",")); err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("expected more users than colors to fail, got %v", err)
+	}
+}
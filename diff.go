@@ -0,0 +1,149 @@
+package gifhub
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// compareDiff scrapes the activity of two years and saves <userhandle>-<yearA>-<yearB>.png
+// in the output directory, comparing both years in a single annotated image
+func compareDiff(userHandle, yearA, yearB, outputDir string, s style, font *truetype.Font, l layout, opts fetchOptions) (string, error) {
+	a, err := parseActivity(userHandle, yearA, opts)
+	if err != nil {
+		return "", fmt.Errorf("scrape activity for %s: %v", yearA, err)
+	}
+	b, err := parseActivity(userHandle, yearB, opts)
+	if err != nil {
+		return "", fmt.Errorf("scrape activity for %s: %v", yearB, err)
+	}
+
+	if err := ensureDir(outputDir); err != nil {
+		return "", err
+	}
+	fileName := fmt.Sprintf("%s-%s-%s.png", userHandle, yearA, yearB)
+	f, err := os.Create(filepath.Join(outputDir, fileName))
+	if err != nil {
+		return "", err
+	}
+
+	if err := png.Encode(f, diffImg(a, b, withFonts(s, font), l)); err != nil {
+		f.Close()
+		return "", err
+	}
+
+	return f.Name(), f.Close()
+}
+
+// diffImg draws the polygons of activities a and b on the same axes,
+// shading the region covered by only one of the polygons
+func diffImg(a, b activity, s style, l layout) image.Image {
+	ca := coordinates(a, l)
+	cb := coordinates(b, l)
+
+	// both activities share the same canvas measurements
+	w := ca.W
+	h := ca.H
+	mid := ca.Mid
+	midY := ca.MidY
+	graphH := ca.GraphH
+	factor := ca.Factor
+	axisMargin := ca.AxisMargin
+
+	aColor := s.ValueColor
+	bColor := s.AxisColor
+	deltaColor := color.RGBA{255, 223, 182, 0xff}
+
+	dc := gg.NewContext(int(w), int(h))
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	// shade the symmetric difference,
+	// the coverage of a pixel is the difference between the coverage of both polygons
+	maskA := polygonMask(ca)
+	maskB := polygonMask(cb)
+	delta := image.NewAlpha(maskA.Bounds())
+	for i := range delta.Pix {
+		if maskA.Pix[i] > maskB.Pix[i] {
+			delta.Pix[i] = maskA.Pix[i] - maskB.Pix[i]
+		} else {
+			delta.Pix[i] = maskB.Pix[i] - maskA.Pix[i]
+		}
+	}
+	canvas := dc.Image().(*image.RGBA)
+	draw.DrawMask(canvas, canvas.Bounds(), image.NewUniform(deltaColor), image.ZP, delta, image.ZP, draw.Over)
+
+	// draw axis
+	dc.SetLineWidth(factor * 0.08)
+	dc.SetColor(s.AxisColor)
+	dc.DrawLine(axisMargin, midY, w-axisMargin, midY)
+	dc.DrawLine(mid, axisMargin, mid, graphH-axisMargin)
+	dc.Stroke()
+
+	// draw polygon outlines
+	dc.SetLineWidth(factor * 0.08)
+	dc.SetColor(aColor)
+	polygon(ca, dc)
+	dc.Stroke()
+	dc.SetColor(bColor)
+	polygon(cb, dc)
+	dc.Stroke()
+
+	// draw text
+	dc.SetFontFace(s.LabelFont)
+	dc.SetColor(s.LabelColor)
+	dc.DrawStringAnchored(a.Handle, mid, h-1.25*factor, 0.5, 0.5)
+	dc.DrawStringAnchored(fmt.Sprintf("%s vs %s", yearLabel(a.Year), yearLabel(b.Year)), mid, h-0.75*factor, 0.5, 0.5)
+	dc.DrawStringAnchored("Code Review", mid, 1.5*factor, 0.5, 0.5)
+	dc.DrawStringAnchored("Issues", w-1.25*factor, midY+0.25*factor, 0.5, 0.5)
+	dc.DrawStringAnchored("Pull Requests", mid, graphH-1.25*factor, 0.5, 0.5)
+	dc.DrawStringAnchored("Commits", 1.25*factor, midY+0.25*factor, 0.5, 0.5)
+
+	dc.SetFontFace(s.ValueFont)
+	dc.SetColor(s.ValueColor)
+	dc.DrawStringAnchored(diffValue(a.CodeReviews, b.CodeReviews), mid, factor, 0.5, 0.5)
+	dc.DrawStringAnchored(diffValue(a.Issues, b.Issues), w-1.25*factor, midY-0.25*factor, 0.5, 0.5)
+	dc.DrawStringAnchored(diffValue(a.Prs, b.Prs), mid, graphH-1.75*factor, 0.5, 0.5)
+	dc.DrawStringAnchored(diffValue(a.Commits, b.Commits), 1.25*factor, midY-0.25*factor, 0.5, 0.5)
+
+	// draw legend
+	legend := []struct {
+		label string
+		color color.Color
+	}{
+		{yearLabel(a.Year), aColor},
+		{yearLabel(b.Year), bColor},
+		{"Difference", deltaColor},
+	}
+	for i, l := range legend {
+		y := h - 1.4*factor + float64(i)*0.4*factor
+		dc.SetColor(l.color)
+		dc.DrawRectangle(0.2*factor, y-0.12*factor, 0.24*factor, 0.24*factor)
+		dc.Fill()
+		dc.SetColor(s.ValueColor)
+		dc.DrawStringAnchored(l.label, 0.6*factor, y, 0, 0.5)
+	}
+
+	return dc.Image()
+}
+
+// polygonMask returns the coverage of the activity polygon described by c
+func polygonMask(c coords) *image.Alpha {
+	dc := gg.NewContext(int(c.W), int(c.H))
+	dc.SetColor(color.Black)
+	polygon(c, dc)
+	dc.Fill()
+	return dc.AsMask()
+}
+
+// diffValue formats the change of a metric between two years
+func diffValue(a, b int) string {
+	return fmt.Sprintf("%d→%d%%", a, b)
+}
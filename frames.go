@@ -0,0 +1,95 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// exportFrames saves every frame as <handle>-<frame>.png in dir, numbered from 000
+// with diff, <handle>-<frame>-diff.png holds the pixels that changed since the previous frame,
+// transparent elsewhere, the diff of the first frame is the whole frame
+func exportFrames(frames []image.Image, dir, handle string, diff bool) error {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	for i, f := range frames {
+		name := frameName(handle, i)
+		if err := writePNG(filepath.Join(dir, name+".png"), f); err != nil {
+			return err
+		}
+		if !diff {
+			continue
+		}
+		var prev image.Image
+		if i > 0 {
+			prev = frames[i-1]
+		}
+		if err := writePNG(filepath.Join(dir, name+"-diff.png"), diffFrame(prev, f)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// frameName returns the name of the i-th frame exported by exportFrames, without extension
+func frameName(handle string, i int) string {
+	return fmt.Sprintf("%s-%03d", handle, i)
+}
+
+// frameMeta describes a frame exported by exportFrames, see writeFramesMetadata
+type frameMeta struct {
+	Index      int               `json:"index"`
+	File       string            `json:"file"`
+	Year       string            `json:"year"`
+	Delay      int               `json:"delay"`           // in hundredths of a second
+	Intro      bool              `json:"intro,omitempty"` // a frame of the --spin intro
+	Title      bool              `json:"title,omitempty"` // the --title frame, of no year
+	Activities []sidecarActivity `json:"activities"`      // several with --compare-users
+}
+
+// writeFramesMetadata writes the frames, in the order they are played, as a JSON array to frames.json in dir
+func writeFramesMetadata(dir string, frames []frameMeta) (string, error) {
+	data, err := json.MarshalIndent(frames, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "frames.json")
+	return path, ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// diffFrame returns the pixels of frame that differ from prev over a transparent background
+// every pixel differs from a nil prev
+func diffFrame(prev, frame image.Image) image.Image {
+	bounds := frame.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if prev == nil || prev.Bounds() != bounds || pixelChanged(prev, frame, x, y) {
+				out.Set(x-bounds.Min.X, y-bounds.Min.Y, frame.At(x, y))
+			}
+		}
+	}
+	return out
+}
+
+// writePNG encodes img to a new PNG file at path
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,124 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffFrame(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	prev := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(prev, prev.Bounds(), image.White, image.Point{}, draw.Src)
+	frame := image.NewRGBA(prev.Bounds())
+	draw.Draw(frame, frame.Bounds(), prev, image.Point{}, draw.Src)
+	changed := image.Rect(5, 5, 10, 10)
+	draw.Draw(frame, changed, image.NewUniform(red), image.Point{}, draw.Src)
+
+	diff := diffFrame(prev, frame)
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			_, _, _, a := diff.At(x, y).RGBA()
+			switch in := image.Pt(x, y).In(changed); {
+			case in && color.NRGBAModel.Convert(diff.At(x, y)) != red:
+				t.Fatalf("expected the changed pixel %d,%d of the frame, got %v", x, y, diff.At(x, y))
+			case !in && a != 0:
+				t.Fatalf("expected the unchanged pixel %d,%d transparent, got %v", x, y, diff.At(x, y))
+			}
+		}
+	}
+
+	// the diff of the first frame is the whole frame
+	first := diffFrame(nil, frame)
+	for _, p := range []image.Point{{0, 0}, {7, 7}, {19, 19}} {
+		if color.NRGBAModel.Convert(first.At(p.X, p.Y)) != color.NRGBAModel.Convert(frame.At(p.X, p.Y)) {
+			t.Errorf("expected the pixel %v of the first frame, got %v", p, first.At(p.X, p.Y))
+		}
+	}
+}
+
+func TestExportDiffFrames(t *testing.T) {
+	// the directory is created
+	dir := filepath.Join(t.TempDir(), "frames")
+	if err := exportFrames(sampleFrames(t, 0), dir, "sample", true); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2*len(sampleActivities) {
+		t.Errorf("expected a frame and a diff per sample year, got %d files", len(files))
+	}
+	for i := range sampleActivities {
+		f, err := os.Open(filepath.Join(dir, fmt.Sprintf("sample-%03d-diff.png", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diff, err := png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the background in the corner is the same in every frame
+		_, _, _, a := diff.At(0, 0).RGBA()
+		if i == 0 && a == 0 {
+			t.Error("expected the diff of the first frame to be the whole frame")
+		}
+		if i > 0 && a != 0 {
+			t.Errorf("frame %d: expected the unchanged corner transparent, got %v", i, diff.At(0, 0))
+		}
+	}
+
+	// the directory may not be a file
+	file := filepath.Join(t.TempDir(), "frames")
+	if err := ioutil.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportFrames([]image.Image{image.NewRGBA(image.Rect(0, 0, 1, 1))}, file, "octocat", true); err == nil {
+		t.Error("expected an error exporting to a file")
+	}
+}
+
+func TestFramesMetadata(t *testing.T) {
+	if order := bounceOrder(4); !reflect.DeepEqual(order, []int{0, 1, 2, 3, 2, 1}) {
+		t.Errorf("expected the frames played forth and back, got %v", order)
+	}
+
+	dir := t.TempDir()
+	act := sidecarActivities([]activity{sampleActivities[0]})
+	frames := []frameMeta{
+		{Index: 0, File: filepath.Join(dir, frameName("sample", 0)+".png"), Year: "2016", Delay: 40, Intro: true},
+		{Index: 1, File: filepath.Join(dir, frameName("sample", 1)+".png"), Year: "2016", Delay: 300, Activities: act},
+	}
+	path, err := writeFramesMetadata(dir, frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "frames.json") {
+		t.Errorf("expected frames.json in the frames directory, got %s", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []frameMeta
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, frames) {
+		t.Errorf("expected %+v, got %+v", frames, got)
+	}
+	if !strings.Contains(string(data), `"file": "`+filepath.Join(dir, "sample-001.png")+`"`) || strings.Count(string(data), `"intro"`) != 1 {
+		t.Errorf("expected the files named as exported and the intro only on its frames, got\n%s", data)
+	}
+}
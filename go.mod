@@ -1,10 +1,18 @@
 module github.com/CamiloGarciaLaRotta/gifhub
 
-go 1.14
+// the WebP encoder, nativewebp v1.3.0, requires go 1.22.2 and golang.org/x/image v0.24.0
+go 1.22.2
 
 require (
+	github.com/HugoSmits86/nativewebp v1.3.0
 	github.com/fogleman/gg v1.3.0
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
 	github.com/urfave/cli/v2 v2.24.4
-	golang.org/x/image v0.0.0-20190321063152-3fc05d484e9f
+	golang.org/x/image v0.24.0
+)
+
+require (
+	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
+	github.com/russross/blackfriday/v2 v2.1.0 // indirect
+	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 )
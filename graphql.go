@@ -0,0 +1,187 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// graphqlURL is the endpoint of GitHub's GraphQL API, GitHub Enterprise serves it at /api/graphql
+const graphqlURL = "https://api.github.com/graphql"
+
+// backends are the valid sources of the activity percentages
+var backends = []string{"html", "graphql"}
+
+// activityFetcher returns the activity of a user on a given year from one of the backends
+type activityFetcher interface {
+	Fetch(handle, year string) (activity, error)
+}
+
+// htmlFetcher scrapes the activity overview of the profile page, it is the html backend
+type htmlFetcher struct {
+	opts fetchOptions
+}
+
+// Fetch implements activityFetcher
+func (f htmlFetcher) Fetch(handle, year string) (activity, error) {
+	return parseActivity(handle, year, f.opts)
+}
+
+// graphqlFetcher counts the contributions with the GraphQL API, it is the graphql backend
+type graphqlFetcher struct {
+	opts fetchOptions
+}
+
+// Fetch implements activityFetcher
+func (f graphqlFetcher) Fetch(handle, year string) (activity, error) {
+	return graphqlActivity(handle, year, f.opts)
+}
+
+// newActivityFetcher returns the fetcher of the named backend, html when empty
+func newActivityFetcher(backend string, opts fetchOptions) (activityFetcher, error) {
+	switch backend {
+	case "", "html":
+		return htmlFetcher{opts}, nil
+	case "graphql":
+		return graphqlFetcher{opts}, nil
+	}
+	return nil, fmt.Errorf("invalid backend %q, must be one of %s", backend, strings.Join(backends, ","))
+}
+
+// contributionsQuery counts the contributions of a user per kind between two dates
+const contributionsQuery = `query($login: String!, $from: DateTime!, $to: DateTime!) {
+  user(login: $login) {
+    contributionsCollection(from: $from, to: $to) {
+      totalCommitContributions
+      totalIssueContributions
+      totalPullRequestContributions
+      totalPullRequestReviewContributions
+    }
+  }
+}`
+
+// viewerQuery returns the login of the owner of the token
+const viewerQuery = `query { viewer { login } }`
+
+// contributions are the counts of the contributionsCollection of a user
+type contributions struct {
+	Commits      int `json:"totalCommitContributions"`
+	Issues       int `json:"totalIssueContributions"`
+	PullRequests int `json:"totalPullRequestContributions"`
+	Reviews      int `json:"totalPullRequestReviewContributions"`
+}
+
+// graphqlActivity returns an activity for a GitHub user on a given year, or range of months, from the contributions counted
+// by the GraphQL API, which requires opts.Token. The counts are turned into percentages of their total,
+// as GitHub does for the activity overview
+func graphqlActivity(userHandle, year string, opts fetchOptions) (activity, error) {
+	var data struct {
+		User *struct {
+			ContributionsCollection contributions `json:"contributionsCollection"`
+		} `json:"user"`
+	}
+	from, to := yearDates(year)
+	variables := map[string]string{
+		"login": userHandle,
+		"from":  from + "T00:00:00Z",
+		"to":    to + "T23:59:59Z",
+	}
+	if err := graphql(contributionsQuery, variables, &data, opts); err != nil {
+		return activity{}, err
+	}
+	if data.User == nil {
+		return activity{}, fmt.Errorf("graphql: no user %s", userHandle)
+	}
+
+	a := contributionsActivity(data.User.ContributionsCollection)
+	a.Handle = userHandle
+	a.Year = year
+	return a, nil
+}
+
+// checkToken fails fast if opts.Token cannot query the GraphQL API, because it is invalid, expired or lacks access,
+// rather than failing the scrape of every year. It returns the login of the owner of the token
+func checkToken(opts fetchOptions) (string, error) {
+	var data struct {
+		Viewer struct {
+			Login string `json:"login"`
+		} `json:"viewer"`
+	}
+	err := graphql(viewerQuery, nil, &data, opts)
+	var status statusError
+	switch {
+	case errors.As(err, &status) && status.Code == http.StatusUnauthorized:
+		return "", errors.New("check token: GitHub rejected the token, it is invalid or expired")
+	case err != nil:
+		return "", fmt.Errorf("check token: %v, the token may lack access to the GraphQL API", err)
+	case data.Viewer.Login == "":
+		return "", errors.New("check token: the token has no access to the identity of its owner")
+	}
+	return data.Viewer.Login, nil
+}
+
+// graphql sends the query with its variables to the GraphQL API and decodes the data of the response into data
+// the errors of the response are returned as a single error
+func graphql(query string, variables map[string]string, data interface{}, opts fetchOptions) error {
+	if opts.Token == "" {
+		return errors.New("graphql: the GraphQL API requires a token")
+	}
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("graphql: %v", err)
+	}
+	endpoint := graphqlURL
+	if opts.BaseURL != "" && opts.BaseURL != githubURL {
+		endpoint = opts.BaseURL + "/api/graphql"
+	}
+	body, _, err := request("POST", endpoint, payload, opts)
+	if err != nil {
+		return fmt.Errorf("graphql: %w", err)
+	}
+
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return fmt.Errorf("graphql: %v", err)
+	}
+	if len(res.Errors) > 0 {
+		messages := []string{}
+		for _, e := range res.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("graphql: %s", strings.Join(messages, ", "))
+	}
+	if len(res.Data) == 0 {
+		return errors.New("graphql: no data in the response")
+	}
+	if err := json.Unmarshal(res.Data, data); err != nil {
+		return fmt.Errorf("graphql: %v", err)
+	}
+	return nil
+}
+
+// contributionsActivity returns the activity of the contribution counts c, as percentages of their total and the total
+// an activity without contributions is 0% on every metric
+func contributionsActivity(c contributions) activity {
+	total := c.Commits + c.Issues + c.PullRequests + c.Reviews
+	if total == 0 {
+		return activity{}
+	}
+	pct := func(n int) int {
+		return int(math.Round(100 * float64(n) / float64(total)))
+	}
+	return activity{
+		Commits:     pct(c.Commits),
+		Issues:      pct(c.Issues),
+		Prs:         pct(c.PullRequests),
+		CodeReviews: pct(c.Reviews),
+		Total:       total,
+	}
+}
@@ -0,0 +1,175 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestContributionsActivity(t *testing.T) {
+	for _, tc := range []struct {
+		c    contributions
+		want activity
+	}{
+		{contributions{Commits: 50, Issues: 25, PullRequests: 15, Reviews: 10}, activity{Commits: 50, Issues: 25, Prs: 15, CodeReviews: 10}},
+		{contributions{Commits: 2, Issues: 1}, activity{Commits: 67, Issues: 33}},
+		{contributions{}, activity{}},
+	} {
+		if got := contributionsActivity(tc.c); got.Commits != tc.want.Commits || got.Issues != tc.want.Issues ||
+			got.Prs != tc.want.Prs || got.CodeReviews != tc.want.CodeReviews {
+			t.Errorf("%+v: expected %+v, got %+v", tc.c, tc.want, got)
+		}
+	}
+}
+
+func TestGraphqlActivity(t *testing.T) {
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.String() != graphqlURL {
+			t.Errorf("expected a POST to %s, got %s %s", graphqlURL, r.Method, r.URL)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "token ghp_secret" {
+			t.Errorf("expected the token, got %q", auth)
+		}
+		var req struct {
+			Variables map[string]string `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		switch req.Variables["login"] {
+		case "octocat":
+			if req.Variables["from"] != "2020-01-01T00:00:00Z" || req.Variables["to"] != "2020-12-31T23:59:59Z" {
+				t.Errorf("expected the bounds of 2020, got %v", req.Variables)
+			}
+			fmt.Fprint(w, `{"data":{"user":{"contributionsCollection":{"totalCommitContributions":3,"totalIssueContributions":1}}}}`)
+		case "ghost":
+			fmt.Fprint(w, `{"data":{"user":null}}`)
+		default:
+			fmt.Fprint(w, `{"errors":[{"message":"bad credentials"},{"message":"try again"}]}`)
+		}
+	})
+	opts := fetchOptions{MaxBytes: 1 << 20, Token: "ghp_secret"}
+
+	act, err := graphqlActivity("octocat", "2020", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act.Handle != "octocat" || act.Year != "2020" || act.Commits != 75 || act.Issues != 25 {
+		t.Errorf("expected the contributions as percentages, got %+v", act)
+	}
+	if _, err := graphqlActivity("ghost", "2020", opts); err == nil || !strings.Contains(err.Error(), "no user ghost") {
+		t.Errorf("expected a missing user to fail, got %v", err)
+	}
+	if _, err := graphqlActivity("hubot", "2020", opts); err == nil || !strings.Contains(err.Error(), "bad credentials, try again") {
+		t.Errorf("expected the errors of the response, got %v", err)
+	}
+	if _, err := graphqlActivity("octocat", "2020", fetchOptions{MaxBytes: 1 << 20}); err == nil || !strings.Contains(err.Error(), "requires a token") {
+		t.Errorf("expected a missing token to fail, got %v", err)
+	}
+}
+
+func TestCheckToken(t *testing.T) {
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "token valid":
+			fmt.Fprint(w, `{"data": {"viewer": {"login": "octocat"}}}`)
+		case "token unscoped":
+			fmt.Fprint(w, `{"data": null, "errors": [{"type": "INSUFFICIENT_SCOPES", "message": "Your token has not been granted the required scopes to execute this query."}]}`)
+		case "token anonymous":
+			fmt.Fprint(w, `{"data": {"viewer": {}}}`)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message": "Bad credentials", "documentation_url": "https://docs.github.com/graphql"}`)
+		}
+	})
+
+	for _, tc := range []struct {
+		token, login, err string
+	}{
+		{"valid", "octocat", ""},
+		{"unscoped", "", "may lack access"},
+		{"anonymous", "", "no access to the identity"},
+		{"expired", "", "invalid or expired"},
+	} {
+		login, err := checkToken(fetchOptions{MaxBytes: 1 << 20, Token: tc.token})
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.token, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: expected %q, got %v", tc.token, tc.err, err)
+		case login != tc.login:
+			t.Errorf("%s: expected the login %q, got %q", tc.token, tc.login, login)
+		}
+	}
+}
+
+func TestActivityFetchers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/graphql" {
+			fmt.Fprint(w, `{"data": {"user": {"contributionsCollection": {"totalCommitContributions": 50,
+				"totalIssueContributions": 25, "totalPullRequestContributions": 15, "totalPullRequestReviewContributions": 10}}}}`)
+			return
+		}
+		fmt.Fprint(w, overviewFixture)
+	}))
+	defer srv.Close()
+	opts := fetchOptions{BaseURL: srv.URL, MaxBytes: 1 << 20, MarkupVersion: "auto", Token: "token"}
+
+	for _, tc := range []struct {
+		backend string
+		want    activity
+	}{
+		{"", activity{Handle: "octocat", Year: "2020", Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}},
+		{"html", activity{Handle: "octocat", Year: "2020", Commits: 60, Issues: 10, Prs: 20, CodeReviews: 10}},
+		{"graphql", activity{Handle: "octocat", Year: "2020", Commits: 50, Issues: 25, Prs: 15, CodeReviews: 10, Total: 100}},
+	} {
+		fetcher, err := newActivityFetcher(tc.backend, opts)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.backend, err)
+		}
+		act, err := fetcher.Fetch("octocat", "2020")
+		if err != nil {
+			t.Fatalf("%q: %v", tc.backend, err)
+		}
+		if act.Handle != tc.want.Handle || act.Year != tc.want.Year || act.Commits != tc.want.Commits || act.Issues != tc.want.Issues ||
+			act.Prs != tc.want.Prs || act.CodeReviews != tc.want.CodeReviews || act.Total != tc.want.Total {
+			t.Errorf("%q: expected %+v, got %+v", tc.backend, tc.want, act)
+		}
+	}
+
+	if _, err := newActivityFetcher("rest", opts); err == nil {
+		t.Error("expected an invalid backend error")
+	}
+}
+
+// yearFetcher is an activityFetcher whose activities hold as many commits as the year
+type yearFetcher struct{}
+
+func (yearFetcher) Fetch(handle, year string) (activity, error) {
+	if year == "2019" {
+		return activity{}, fmt.Errorf("no activity in %s", year)
+	}
+	var n int
+	fmt.Sscan(year, &n)
+	return activity{Handle: handle, Year: year, Commits: n}, nil
+}
+
+func TestGenActivitiesFetcher(t *testing.T) {
+	years := []string{"2018", "2019", "2020"}
+	got := []string{}
+	for act := range genActivities("octocat", genYears(years, len(years)), len(years), 2, yearFetcher{}) {
+		if fmt.Sprint(act.Commits) != act.Year {
+			t.Errorf("%s: expected the activity of the fetcher, got %+v", act.Year, act)
+		}
+		got = append(got, act.Year)
+	}
+	sort.Strings(got)
+	// the year that failed to be fetched is left out
+	if fmt.Sprint(got) != "[2018 2020]" {
+		t.Errorf("expected the activities of 2018 and 2020, got %v", got)
+	}
+}
@@ -0,0 +1,119 @@
+package gifhub
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// metrics counts the work done during a run, to be written in Prometheus text format
+// all methods are no-ops on a nil *metrics, so disabled metrics cost a nil check
+type metrics struct {
+	requests, requestErrors int64
+	scrapes, scrapeErrors   int64
+	renders, renderNanos    int64
+	encodes, encodeNanos    int64
+}
+
+// request records an HTTP request to GitHub
+func (m *metrics) request(err error) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.requests, 1)
+	if err != nil {
+		atomic.AddInt64(&m.requestErrors, 1)
+	}
+}
+
+// scrape records the scrape of the activity of a year
+func (m *metrics) scrape(err error) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.scrapes, 1)
+	if err != nil {
+		atomic.AddInt64(&m.scrapeErrors, 1)
+	}
+}
+
+// render records the rendering of a frame that started at start
+func (m *metrics) render(start time.Time) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.renders, 1)
+	atomic.AddInt64(&m.renderNanos, int64(time.Since(start)))
+}
+
+// encode records the encoding of an output file that started at start
+func (m *metrics) encode(start time.Time) {
+	if m == nil {
+		return
+	}
+	atomic.AddInt64(&m.encodes, 1)
+	atomic.AddInt64(&m.encodeNanos, int64(time.Since(start)))
+}
+
+// withMetrics returns a scraper recording every scrape of scrape in m
+func withMetrics(scrape scraper, m *metrics) scraper {
+	if m == nil {
+		return scrape
+	}
+	return func(handle, year string) (activity, error) {
+		act, err := scrape(handle, year)
+		m.scrape(err)
+		return act, err
+	}
+}
+
+// write writes the metrics in Prometheus text exposition format
+func (m *metrics) write(w io.Writer) error {
+	load := func(counter *int64) int64 { return atomic.LoadInt64(counter) }
+	seconds := func(nanos *int64) float64 { return time.Duration(load(nanos)).Seconds() }
+
+	_, err := fmt.Fprintf(w, `# HELP gifhub_http_requests_total HTTP requests issued to GitHub.
+# TYPE gifhub_http_requests_total counter
+gifhub_http_requests_total %d
+# HELP gifhub_http_request_errors_total HTTP requests to GitHub that failed before receiving a response.
+# TYPE gifhub_http_request_errors_total counter
+gifhub_http_request_errors_total %d
+# HELP gifhub_scrapes_total Activities scraped, by result.
+# TYPE gifhub_scrapes_total counter
+gifhub_scrapes_total{result="success"} %d
+gifhub_scrapes_total{result="failure"} %d
+# HELP gifhub_render_duration_seconds Time spent rendering frames.
+# TYPE gifhub_render_duration_seconds summary
+gifhub_render_duration_seconds_sum %g
+gifhub_render_duration_seconds_count %d
+# HELP gifhub_encode_duration_seconds Time spent encoding output files.
+# TYPE gifhub_encode_duration_seconds summary
+gifhub_encode_duration_seconds_sum %g
+gifhub_encode_duration_seconds_count %d
+`,
+		load(&m.requests),
+		load(&m.requestErrors),
+		load(&m.scrapes)-load(&m.scrapeErrors),
+		load(&m.scrapeErrors),
+		seconds(&m.renderNanos),
+		load(&m.renders),
+		seconds(&m.encodeNanos),
+		load(&m.encodes),
+	)
+	return err
+}
+
+// writeMetricsFile writes the metrics to path in Prometheus text exposition format
+func writeMetricsFile(m *metrics, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,140 @@
+package gifhub
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// promSample is a sample line of the Prometheus text format, name{labels} value
+var promSample = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{[a-zA-Z_][a-zA-Z0-9_]*="[^"]*"(,[a-zA-Z_][a-zA-Z0-9_]*="[^"]*")*\})? (\S+)$`)
+
+// parseProm parses the Prometheus text format into the values of the samples by name and labels
+// every sample must belong to a family whose HELP and TYPE precede it
+func parseProm(t *testing.T, r io.Reader) map[string]float64 {
+	t.Helper()
+	samples := map[string]float64{}
+	types := map[string]string{}
+	help := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(line, " ", 4)
+			if len(fields) < 4 {
+				t.Fatalf("line %d: malformed comment %q", n, line)
+			}
+			switch fields[1] {
+			case "HELP":
+				help[fields[2]] = true
+			case "TYPE":
+				switch fields[3] {
+				case "counter", "gauge", "summary", "histogram", "untyped":
+				default:
+					t.Fatalf("line %d: unknown type %q", n, fields[3])
+				}
+				types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		m := promSample.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("line %d: malformed sample %q", n, line)
+		}
+		family := m[1]
+		if types[family] == "summary" || types[family] == "" {
+			family = strings.TrimSuffix(strings.TrimSuffix(family, "_sum"), "_count")
+		}
+		if types[family] == "" || !help[family] {
+			t.Fatalf("line %d: %s has no preceding HELP and TYPE", n, m[1])
+		}
+		v, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			t.Fatalf("line %d: %v", n, err)
+		}
+		samples[m[1]+m[2]] = v
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func TestMetricsFormat(t *testing.T) {
+	m := &metrics{}
+	m.request(nil)
+	m.request(errors.New("refused"))
+	m.scrape(nil)
+	m.scrape(nil)
+	m.scrape(errors.New("blocked"))
+	m.render(time.Now().Add(-time.Second))
+	m.encode(time.Now())
+
+	var buf bytes.Buffer
+	if err := m.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	samples := parseProm(t, &buf)
+	for name, want := range map[string]float64{
+		"gifhub_http_requests_total":             2,
+		"gifhub_http_request_errors_total":       1,
+		`gifhub_scrapes_total{result="success"}`: 2,
+		`gifhub_scrapes_total{result="failure"}`: 1,
+		"gifhub_render_duration_seconds_count":   1,
+		"gifhub_encode_duration_seconds_count":   1,
+	} {
+		if got, ok := samples[name]; !ok || got != want {
+			t.Errorf("%s: expected %g, got %g (present %v)", name, want, got, ok)
+		}
+	}
+	if got := samples["gifhub_render_duration_seconds_sum"]; got < 1 {
+		t.Errorf("expected at least a second of rendering, got %g", got)
+	}
+}
+
+func TestMetricsFile(t *testing.T) {
+	m := &metrics{}
+	scrape := withMetrics(func(handle, year string) (activity, error) {
+		if year == "2020" {
+			return activity{}, errors.New("blocked")
+		}
+		return activity{Handle: handle, Year: year}, nil
+	}, m)
+	for _, year := range []string{"2019", "2020", "2021"} {
+		scrape("octocat", year)
+	}
+
+	path := filepath.Join(t.TempDir(), "metrics.prom")
+	if err := writeMetricsFile(m, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := parseProm(t, bytes.NewReader(data))
+	if got := samples[`gifhub_scrapes_total{result="success"}`]; got != 2 {
+		t.Errorf("expected the 2 scrapes that succeeded, got %g", got)
+	}
+	if got := samples[`gifhub_scrapes_total{result="failure"}`]; got != 1 {
+		t.Errorf("expected the scrape that failed, got %g", got)
+	}
+
+	// the methods of a disabled metrics are no-ops
+	var disabled *metrics
+	disabled.request(nil)
+	disabled.scrape(nil)
+	disabled.render(time.Now())
+	disabled.encode(time.Now())
+	if act, err := withMetrics(scrape, disabled)("octocat", "2019"); err != nil || act.Year != "2019" {
+		t.Errorf("expected the scraper unchanged without metrics, got %+v %v", act, err)
+	}
+}
@@ -0,0 +1,85 @@
+package gifhub
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"sort"
+)
+
+// palettes are the valid color palettes of the GIF frames
+var palettes = []string{"plan9", "websafe", "adaptive"}
+
+// gifPalette returns the named palette of the frames, with a transparent color if transparent
+// the GIF encoder sets the first transparent color of the palette as the transparent index
+func gifPalette(name string, frames []image.Image, transparent bool) color.Palette {
+	var p color.Palette
+	switch name {
+	case "websafe":
+		p = append(p, palette.WebSafe...)
+	case "adaptive":
+		size := 256
+		if transparent {
+			size--
+		}
+		p = adaptivePalette(frames, size)
+	default:
+		p = append(p, palette.Plan9...)
+	}
+	if !transparent {
+		return p
+	}
+	if len(p) < 256 {
+		return append(p, color.Transparent)
+	}
+	// Plan9 has no room for another color, the dark blue {0, 0, 68} gives way to it
+	p[1] = color.Transparent
+	return p
+}
+
+// adaptivePalette returns the size most frequent colors of the frames, without the transparent pixels
+// the graphs are drawn in a few greens and grays, which a fixed palette only approximates
+func adaptivePalette(frames []image.Image, size int) color.Palette {
+	counts := map[color.RGBA]int{}
+	for _, f := range frames {
+		b := f.Bounds()
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if c := color.RGBAModel.Convert(f.At(x, y)).(color.RGBA); c.A > 0 {
+					counts[c]++
+				}
+			}
+		}
+	}
+
+	colors := make([]color.RGBA, 0, len(counts))
+	for c := range counts {
+		colors = append(colors, c)
+	}
+	// ties are broken by the color itself, so that the palette is the same on every run
+	sort.Slice(colors, func(i, j int) bool {
+		ci, cj := colors[i], colors[j]
+		if counts[ci] != counts[cj] {
+			return counts[ci] > counts[cj]
+		}
+		if ci.R != cj.R {
+			return ci.R < cj.R
+		}
+		if ci.G != cj.G {
+			return ci.G < cj.G
+		}
+		if ci.B != cj.B {
+			return ci.B < cj.B
+		}
+		return ci.A < cj.A
+	})
+	if len(colors) > size {
+		colors = colors[:size]
+	}
+
+	p := make(color.Palette, len(colors))
+	for i, c := range colors {
+		p[i] = c
+	}
+	return p
+}
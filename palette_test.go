@@ -0,0 +1,66 @@
+package gifhub
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"testing"
+)
+
+func TestGifPalette(t *testing.T) {
+	frames := sampleFrames(t, 0)
+	for _, tc := range []struct {
+		name        string
+		transparent bool
+		size        int
+	}{
+		{"plan9", false, len(palette.Plan9)},
+		{"plan9", true, len(palette.Plan9)},
+		{"websafe", false, len(palette.WebSafe)},
+		{"websafe", true, len(palette.WebSafe) + 1},
+		{"adaptive", true, 256},
+	} {
+		p := gifPalette(tc.name, frames, tc.transparent)
+		if len(p) != tc.size {
+			t.Errorf("%s transparent %v: expected %d colors, got %d", tc.name, tc.transparent, tc.size, len(p))
+		}
+		transparent := 0
+		for _, c := range p {
+			if _, _, _, a := c.RGBA(); a == 0 {
+				transparent++
+			}
+		}
+		if want := map[bool]int{false: 0, true: 1}[tc.transparent]; transparent != want {
+			t.Errorf("%s transparent %v: expected %d transparent colors, got %d", tc.name, tc.transparent, want, transparent)
+		}
+	}
+}
+
+func TestAdaptivePalette(t *testing.T) {
+	green, gray := color.RGBA{0x40, 0xc4, 0x63, 0xff}, color.RGBA{0x58, 0x60, 0x69, 0xff}
+	m := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(m, m.Bounds(), image.NewUniform(green), image.Point{}, draw.Src)
+	draw.Draw(m, image.Rect(0, 0, 3, 10), image.NewUniform(gray), image.Point{}, draw.Src)
+	draw.Draw(m, image.Rect(0, 0, 1, 1), image.Transparent, image.Point{}, draw.Src)
+
+	p := adaptivePalette([]image.Image{m}, 256)
+	if len(p) != 2 || p[0] != green || p[1] != gray {
+		t.Errorf("expected the colors of the frame by frequency, without the transparent pixels, got %v", p)
+	}
+	if p := adaptivePalette([]image.Image{m}, 1); len(p) != 1 || p[0] != green {
+		t.Errorf("expected the most frequent color, got %v", p)
+	}
+
+	// the palette of the sample frames is the same on every run
+	frames := sampleFrames(t, 0)
+	first := adaptivePalette(frames, 255)
+	for i := 0; i < 3; i++ {
+		again := adaptivePalette(frames, 255)
+		for j := range first {
+			if again[j] != first[j] {
+				t.Fatalf("run %d: expected the same palette, the color %d differs", i, j)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package gifhub
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// progressBarWidth is the number of characters of the progress bar
+const progressBarWidth = 20
+
+// progress reports how many of the expected activities were scraped and frames rendered, a line per step
+// all methods are no-ops on a nil *progress, so a quiet run costs a nil check
+type progress struct {
+	mu                 sync.Mutex
+	w                  io.Writer
+	scrapes, frames    int // expected, 0 scrapes for the sample activity and 0 frames when nothing is rendered
+	scraped, rendered  int
+	failed, renderFail int
+}
+
+// newProgress returns a progress writing to w, expecting the given number of scrapes and frames
+func newProgress(w io.Writer, scrapes, frames int) *progress {
+	return &progress{w: w, scrapes: scrapes, frames: frames}
+}
+
+// scrape records the scrape of the activity of a year, failed or not
+func (p *progress) scrape(err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scraped++
+	if err != nil {
+		p.failed++
+	}
+	p.print()
+}
+
+// render records the rendering of a frame, failed or not
+func (p *progress) render(err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rendered++
+	if err != nil {
+		p.renderFail++
+	}
+	p.print()
+}
+
+// print writes the progress line, the bar is filled by the steps done out of all the expected scrapes and frames
+func (p *progress) print() {
+	filled := 0
+	if total := p.scrapes + p.frames; total > 0 {
+		filled = (p.scraped + p.rendered) * progressBarWidth / total
+	}
+	if filled > progressBarWidth {
+		filled = progressBarWidth
+	}
+	line := fmt.Sprintf("[%s%s]", strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled))
+	if p.scrapes > 0 {
+		line += fmt.Sprintf(" scraped [%d/%d]", p.scraped, p.scrapes)
+		if p.failed > 0 {
+			line += fmt.Sprintf(" (%d failed)", p.failed)
+		}
+	}
+	if p.frames > 0 {
+		line += fmt.Sprintf(" rendered [%d/%d]", p.rendered, p.frames)
+		if p.renderFail > 0 {
+			line += fmt.Sprintf(" (%d failed)", p.renderFail)
+		}
+	}
+	fmt.Fprintln(p.w, line)
+}
+
+// withProgress returns a scraper recording every scrape of scrape in p
+func withProgress(scrape scraper, p *progress) scraper {
+	if p == nil {
+		return scrape
+	}
+	return func(handle, year string) (activity, error) {
+		act, err := scrape(handle, year)
+		p.scrape(err)
+		return act, err
+	}
+}
+
+// trackRenders passes the images of the input channel through, recording their rendering in p
+func trackRenders(in <-chan activityImage, size int, p *progress) <-chan activityImage {
+	if p == nil {
+		return in
+	}
+	var out = make(chan activityImage, size)
+	go func() {
+		defer close(out)
+		for i := range in {
+			p.render(i.Err)
+			out <- i
+		}
+	}()
+	return out
+}
@@ -0,0 +1,52 @@
+package gifhub
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgress(&buf, 2, 2)
+	scrape := withProgress(func(handle, year string) (activity, error) {
+		if year == "2019" {
+			return activity{}, errors.New("not found")
+		}
+		return activity{Handle: handle, Year: year}, nil
+	}, p)
+	for _, year := range []string{"2019", "2020"} {
+		scrape("octocat", year)
+	}
+	in := make(chan activityImage, 2)
+	in <- activityImage{Year: "2020"}
+	in <- activityImage{Year: "2021", Err: errors.New("timeout")}
+	close(in)
+	for range trackRenders(in, 2, p) {
+	}
+
+	want := `[#####---------------] scraped [1/2] (1 failed) rendered [0/2]
+[##########----------] scraped [2/2] (1 failed) rendered [0/2]
+[###############-----] scraped [2/2] (1 failed) rendered [1/2]
+[####################] scraped [2/2] (1 failed) rendered [2/2] (1 failed)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	// the sample is not scraped, and a report renders nothing
+	buf.Reset()
+	newProgress(&buf, 0, 1).render(nil)
+	newProgress(&buf, 1, 0).scrape(nil)
+	if want := "[####################] rendered [1/1]\n[####################] scraped [1/1]\n"; buf.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, buf.String())
+	}
+
+	// a quiet run has no progress
+	var quiet *progress
+	quiet.scrape(nil)
+	quiet.render(nil)
+	if in := make(chan activityImage); trackRenders(in, 0, nil) != in {
+		t.Error("expected the images passed through untracked")
+	}
+}
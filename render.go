@@ -0,0 +1,68 @@
+package gifhub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// renderClock is the context of the rendering and encoding stages, done once they exceed the render timeout
+// the rendering overlaps the scraping, so the timeout only starts once the scraping is over, see start
+type renderClock struct {
+	Ctx     context.Context
+	cancel  context.CancelCauseFunc
+	timeout time.Duration // zero to not bound the stages
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// newRenderClock returns a clock whose context is done when parent is, or timeout after start is called
+func newRenderClock(parent context.Context, timeout time.Duration) *renderClock {
+	ctx, cancel := context.WithCancelCause(parent)
+	return &renderClock{Ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+// start starts the timeout, only the first call has an effect
+func (r *renderClock) start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timeout == 0 || r.timer != nil {
+		return
+	}
+	err := fmt.Errorf("exceeded the render timeout of %v", r.timeout)
+	r.timer = time.AfterFunc(r.timeout, func() { r.cancel(err) })
+}
+
+// stop releases the clock once the stages are over
+func (r *renderClock) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+	r.cancel(nil)
+}
+
+// stopped returns why ctx is done, such as the render timeout, nil while it is not
+func stopped(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// contextWriter fails the writes to W once Ctx is done, so that an encoder writing to it stops early
+type contextWriter struct {
+	Ctx context.Context
+	W   io.Writer
+}
+
+func (w contextWriter) Write(p []byte) (int, error) {
+	if err := stopped(w.Ctx); err != nil {
+		return 0, err
+	}
+	return w.W.Write(p)
+}
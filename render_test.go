@@ -0,0 +1,99 @@
+package gifhub
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color/palette"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateGIFRemovesPartial(t *testing.T) {
+	dir := t.TempDir()
+	frames := sampleFrames(t, 0)
+	failing := func(ctx context.Context, w io.Writer, frames []image.Image, opts gifOptions) error {
+		w.Write([]byte("GIF89a"))
+		return errors.New("encode failed")
+	}
+	if _, err := createGIF(context.Background(), frames, dir, "sample", "gif", gifOptions{Delay: 100}, failing); err == nil {
+		t.Fatal("expected the error of the encoder")
+	}
+
+	// past the render timeout no frame is encoded either
+	clock := newRenderClock(context.Background(), time.Nanosecond)
+	defer clock.stop()
+	clock.start()
+	<-clock.Ctx.Done()
+	_, err := createGIF(clock.Ctx, frames, dir, "sample", "gif", gifOptions{Delay: 100, Palette: palette.Plan9}, encodeGIF)
+	if err == nil || !strings.Contains(err.Error(), "exceeded the render timeout of 1ns") {
+		t.Errorf("expected the render timeout error, got %v", err)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		t.Errorf("expected the partial output removed, found %s", f.Name())
+	}
+}
+
+func TestRenderClockStartsOnce(t *testing.T) {
+	clock := newRenderClock(context.Background(), time.Millisecond)
+	defer clock.stop()
+	time.Sleep(5 * time.Millisecond)
+	if err := stopped(clock.Ctx); err != nil {
+		t.Fatalf("expected the timeout to wait for start, got %v", err)
+	}
+
+	clock.start()
+	clock.start()
+	select {
+	case <-clock.Ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the clock to time out")
+	}
+	if err := stopped(clock.Ctx); err == nil || !strings.Contains(err.Error(), "render timeout") {
+		t.Errorf("expected the render timeout error, got %v", err)
+	}
+}
+
+func TestEncodeGIFStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	frames := []image.Image{image.NewRGBA(image.Rect(0, 0, 10, 10))}
+	var buf bytes.Buffer
+	err := encodeGIF(ctx, &buf, frames, gifOptions{Delay: 100, Palette: palette.Plan9})
+	if err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if buf.Len() > 0 {
+		t.Errorf("expected nothing written once the context is done, got %d bytes", buf.Len())
+	}
+}
+
+func TestBundleImgsDrains(t *testing.T) {
+	in := make(chan activityImage)
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		defer close(in)
+		in <- activityImage{Year: "2019", Err: context.Canceled}
+		// a receiver that stopped at the first error would block these sends forever
+		in <- activityImage{Year: "2020", Err: context.Canceled}
+		in <- activityImage{Year: "2021", Err: context.Canceled}
+	}()
+	if _, err := bundleImgs(in, 0, false, nil); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("expected the channel to be drained")
+	}
+}
@@ -0,0 +1,100 @@
+package gifhub
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// reportBarWidth is the number of characters of the bar of a metric at 100%
+const reportBarWidth = 20
+
+// reportMetric is a metric of an activity as printed by --report
+type reportMetric struct {
+	Name string
+	Pct  int
+}
+
+// reportMetrics returns the metrics of activity a in the order of the report
+func reportMetrics(a activity) []reportMetric {
+	return []reportMetric{
+		{"Commits", a.Commits},
+		{"Issues", a.Issues},
+		{"Pull requests", a.Prs},
+		{"Code review", a.CodeReviews},
+	}
+}
+
+// reportBar returns a bar of block characters as long as pct percent of reportBarWidth
+func reportBar(pct int) string {
+	n := (pct*reportBarWidth + 50) / 100
+	if n > reportBarWidth {
+		n = reportBarWidth
+	}
+	if pct > 0 && n == 0 {
+		n = 1 // so that a tiny metric is not mistaken for none
+	}
+	return strings.Repeat("█", n)
+}
+
+// writeTable writes the activities to w as a table with a row per handle and year, sorted by handle and year
+func writeTable(w io.Writer, acts []activity) error {
+	sort.Slice(acts, func(i, j int) bool {
+		if acts[i].Handle != acts[j].Handle {
+			return acts[i].Handle < acts[j].Handle
+		}
+		return acts[i].Year < acts[j].Year
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HANDLE\tYEAR\tCOMMITS\tISSUES\tPULL REQUESTS\tCODE REVIEW")
+	for _, a := range acts {
+		fmt.Fprintf(tw, "%s\t%s\t%d%%\t%d%%\t%d%%\t%d%%\n", a.Handle, yearLabel(a.Year), a.Commits, a.Issues, a.Prs, a.CodeReviews)
+	}
+	return tw.Flush()
+}
+
+// writeReport writes a summary of the activities to w, sorted by handle and year: a bar per metric of every year,
+// followed by the average and peak of every metric of each handle
+func writeReport(w io.Writer, acts []activity) error {
+	sort.Slice(acts, func(i, j int) bool {
+		if acts[i].Handle != acts[j].Handle {
+			return acts[i].Handle < acts[j].Handle
+		}
+		return acts[i].Year < acts[j].Year
+	})
+
+	var b strings.Builder
+	for start := 0; start < len(acts); {
+		end := start
+		for end < len(acts) && acts[end].Handle == acts[start].Handle {
+			end++
+		}
+		byHandle := acts[start:end]
+
+		peaks := reportMetrics(activity{})
+		peakYears := make([]string, len(peaks))
+		for _, a := range byHandle {
+			fmt.Fprintf(&b, "%s %s\n", a.Handle, yearLabel(a.Year))
+			for i, m := range reportMetrics(a) {
+				line := fmt.Sprintf("  %-13s %4d%%  %s", m.Name, m.Pct, reportBar(m.Pct))
+				b.WriteString(strings.TrimRight(line, " ") + "\n")
+				if m.Pct > peaks[i].Pct || peakYears[i] == "" {
+					peaks[i].Pct, peakYears[i] = m.Pct, yearLabel(a.Year)
+				}
+			}
+		}
+
+		avg := averageActivity(byHandle)
+		fmt.Fprintf(&b, "%s overall\n", byHandle[0].Handle)
+		for i, m := range reportMetrics(avg) {
+			fmt.Fprintf(&b, "  %-13s %4d%% on average, peak %d%% in %s\n", m.Name, m.Pct, peaks[i].Pct, peakYears[i])
+		}
+		b.WriteString("\n")
+		start = end
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,83 @@
+package gifhub
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReportBar(t *testing.T) {
+	for pct, want := range map[int]int{0: 0, 1: 1, 2: 1, 3: 1, 50: 10, 100: reportBarWidth, 120: reportBarWidth} {
+		if got := len([]rune(reportBar(pct))); got != want {
+			t.Errorf("%d%%: expected a bar of %d, got %d", pct, want, got)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	acts := []activity{
+		{Handle: "octocat", Year: "2020", Commits: 40, Issues: 10, Prs: 30, CodeReviews: 20},
+		{Handle: "hubot", Year: "2021-03:2021-09", Commits: 100},
+		{Handle: "octocat", Year: "2019", Commits: 80, Issues: 0, Prs: 18, CodeReviews: 2},
+	}
+	var buf bytes.Buffer
+	if err := writeReport(&buf, acts); err != nil {
+		t.Fatal(err)
+	}
+	want := `hubot 2021 Mar–Sep
+  Commits        100%  ████████████████████
+  Issues           0%
+  Pull requests    0%
+  Code review      0%
+hubot overall
+  Commits        100% on average, peak 100% in 2021 Mar–Sep
+  Issues           0% on average, peak 0% in 2021 Mar–Sep
+  Pull requests    0% on average, peak 0% in 2021 Mar–Sep
+  Code review      0% on average, peak 0% in 2021 Mar–Sep
+
+octocat 2019
+  Commits         80%  ████████████████
+  Issues           0%
+  Pull requests   18%  ████
+  Code review      2%  █
+octocat 2020
+  Commits         40%  ████████
+  Issues          10%  ██
+  Pull requests   30%  ██████
+  Code review     20%  ████
+octocat overall
+  Commits         60% on average, peak 80% in 2019
+  Issues           5% on average, peak 10% in 2020
+  Pull requests   24% on average, peak 30% in 2020
+  Code review     11% on average, peak 20% in 2020
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("expected the report\n%s\ngot\n%s", want, got)
+	}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.TrimRight(line, " ") != line {
+			t.Errorf("expected no trailing spaces, got %q", line)
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	acts := []activity{
+		{Handle: "octocat", Year: "2020", Commits: 40, Issues: 10, Prs: 30, CodeReviews: 20},
+		{Handle: "hubot", Year: "2021-03:2021-09", Commits: 100},
+		{Handle: "octocat", Year: "2019", Commits: 80, Prs: 18, CodeReviews: 2},
+	}
+	var buf bytes.Buffer
+	if err := writeTable(&buf, acts); err != nil {
+		t.Fatal(err)
+	}
+	want := `HANDLE   YEAR          COMMITS  ISSUES  PULL REQUESTS  CODE REVIEW
+hubot    2021 Mar–Sep  100%     0%      0%             0%
+octocat  2019          80%      0%      18%            2%
+octocat  2020          40%      10%     30%            20%
+`
+	if got := buf.String(); got != want {
+		t.Errorf("expected the table\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,137 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+)
+
+// sidecar is the generation metadata written next to a GIF by --sidecar
+type sidecar struct {
+	Version    string                 `json:"version"`
+	Created    string                 `json:"created,omitempty"` // RFC 3339, left out for --reproducible
+	Options    map[string]interface{} `json:"options"`
+	Activities []sidecarActivity      `json:"activities"`
+	Frames     int                    `json:"frames"`
+	Delay      int                    `json:"delay"` // transition delay after --fps and --boomerang
+	Width      int                    `json:"width"`
+	Height     int                    `json:"height"`
+	Bytes      int64                  `json:"bytes"`
+}
+
+// sidecarActivity is the activity of a frame, without the contributions calendar
+type sidecarActivity struct {
+	Handle      string `json:"handle"`
+	Year        string `json:"year"`
+	Commits     int    `json:"commits"`
+	Issues      int    `json:"issues"`
+	Prs         int    `json:"prs"`
+	CodeReviews int    `json:"codeReviews"`
+	Streak      int    `json:"streak,omitempty"`
+	Total       int    `json:"total,omitempty"`
+}
+
+// toolVersion returns the module version gifhub was built from, (devel) for local builds
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
+// recordActivities passes the graphs of the input channel through, appending their activities to acts
+// acts is complete once the output channel is closed
+func recordActivities(in <-chan graph, acts *[]activity, size int) <-chan graph {
+	var out = make(chan graph, size)
+	go func() {
+		defer close(out)
+		for g := range in {
+			if len(g.Series) > 0 {
+				for _, sr := range g.Series {
+					*acts = append(*acts, sr.Data)
+				}
+			} else {
+				*acts = append(*acts, g.Data)
+			}
+			out <- g
+		}
+	}()
+	return out
+}
+
+// sidecarActivities returns the activities sorted by year then handle
+func sidecarActivities(acts []activity) []sidecarActivity {
+	sort.Slice(acts, func(i, j int) bool {
+		if acts[i].Year != acts[j].Year {
+			return acts[i].Year < acts[j].Year
+		}
+		return acts[i].Handle < acts[j].Handle
+	})
+	out := make([]sidecarActivity, len(acts))
+	for i, a := range acts {
+		streak := a.Streak
+		if streak == unknownStreak {
+			streak = 0 // left out
+		}
+		out[i] = sidecarActivity{a.Handle, a.Year, a.Commits, a.Issues, a.Prs, a.CodeReviews, streak, a.Total}
+	}
+	return out
+}
+
+// writeActivities writes the activities as a JSON array to path, or to the standard output if path is -
+func writeActivities(path string, acts []activity) error {
+	data, err := json.MarshalIndent(sidecarActivities(acts), "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// writeSidecar saves meta as <gif>.json, the size of the GIF is read from the file
+// the file is written to a temporary file first so a reader never sees it partially written
+func writeSidecar(gif string, meta sidecar) (string, error) {
+	info, err := os.Stat(gif)
+	if err != nil {
+		return "", err
+	}
+	meta.Bytes = info.Size()
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := gif + ".json"
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	// temporary files are only readable by their owner, unlike the GIF
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, nil
+}
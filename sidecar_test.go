@@ -0,0 +1,121 @@
+package gifhub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSidecar(t *testing.T) {
+	dir := t.TempDir()
+	gif := filepath.Join(dir, "octocat.gif")
+	if err := ioutil.WriteFile(gif, []byte("GIF89a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	acts := sidecarActivities([]activity{
+		{Handle: "octocat", Year: "2021", Commits: 50, Days: []day{{Date: "2021-01-01", Level: 1}}},
+		{Handle: "octocat", Year: "2020", Commits: 60, Streak: 3},
+		{Handle: "monalisa", Year: "2021", Commits: 40},
+	})
+	meta := sidecar{Version: "(devel)", Options: map[string]interface{}{"delay": "40", "handles": []string{"octocat", "monalisa"}}, Activities: acts, Frames: 2, Delay: 40, Width: 500, Height: 560}
+	path, err := writeSidecar(gif, meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != gif+".json" {
+		t.Errorf("expected the sidecar next to the GIF, got %s", path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got sidecar
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Bytes != 6 {
+		t.Errorf("expected the size of the GIF, got %d", got.Bytes)
+	}
+	if got.Version != meta.Version || got.Options["delay"] != "40" || got.Frames != 2 || got.Delay != 40 || got.Width != 500 || got.Height != 560 {
+		t.Errorf("expected the metadata %+v, got %+v", meta, got)
+	}
+	if handles := fmt.Sprint(got.Options["handles"]); handles != "[octocat monalisa]" {
+		t.Errorf("expected the handles written as a list, got %s", handles)
+	}
+	// sorted by year then handle
+	order := []string{"octocat 2020", "monalisa 2021", "octocat 2021"}
+	if len(got.Activities) != len(order) {
+		t.Fatalf("expected the 3 activities, got %v", got.Activities)
+	}
+	for i, a := range got.Activities {
+		if a.Handle+" "+a.Year != order[i] {
+			t.Errorf("activity %d: expected %s, got %s %s", i, order[i], a.Handle, a.Year)
+		}
+	}
+	if got.Activities[0].Streak != 3 || got.Activities[0].Commits != 60 {
+		t.Errorf("expected the metrics of the activity, got %+v", got.Activities[0])
+	}
+
+	// the temporary file is renamed over the sidecar
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		for _, f := range files {
+			t.Errorf("expected only the GIF and the sidecar, found %s", f.Name())
+		}
+	}
+}
+
+func TestWriteSidecarMissingGIF(t *testing.T) {
+	gif := filepath.Join(t.TempDir(), "missing.gif")
+	if _, err := writeSidecar(gif, sidecar{}); err == nil {
+		t.Error("expected an error without the GIF")
+	}
+	if _, err := os.Stat(gif + ".json"); !os.IsNotExist(err) {
+		t.Errorf("expected no sidecar, got %v", err)
+	}
+}
+
+func TestWriteActivities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "activities.json")
+	acts := []activity{
+		{Handle: "octocat", Year: "2020", Commits: 40, Issues: 10, Prs: 30, CodeReviews: 20},
+		{Handle: "octocat", Year: "2019", Commits: 100, Streak: 12},
+	}
+	if err := writeActivities(path, acts); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "handle": "octocat",
+    "year": "2019",
+    "commits": 100,
+    "issues": 0,
+    "prs": 0,
+    "codeReviews": 0,
+    "streak": 12
+  },
+  {
+    "handle": "octocat",
+    "year": "2020",
+    "commits": 40,
+    "issues": 10,
+    "prs": 30,
+    "codeReviews": 20
+  }
+]
+`
+	if string(data) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, data)
+	}
+}
@@ -0,0 +1,86 @@
+package gifhub
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+// gifTrailer is the byte ending every GIF file
+const gifTrailer = 0x3B
+
+// secretParams are the substrings of the query parameters whose values are redacted from source URLs
+var secretParams = []string{"token", "secret", "key", "password", "auth"}
+
+// sourceURLs returns the URLs scraped for the activities of the users on the years
+func sourceURLs(base string, handles, years []string, calendar, streak bool) []string {
+	urls := []string{}
+	for _, handle := range handles {
+		for _, year := range years {
+			if !calendar {
+				urls = append(urls, activityURL(base, handle, year))
+			}
+			if calendar || streak {
+				urls = append(urls, calendarURL(base, handle, year))
+			}
+		}
+	}
+	return urls
+}
+
+// redactURL removes the credentials and the values of the secret query parameters from a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "REDACTED"
+	}
+	u.User = nil
+
+	query := u.Query()
+	redacted := false
+	for name := range query {
+		for _, secret := range secretParams {
+			if strings.Contains(strings.ToLower(name), secret) {
+				query.Set(name, "REDACTED")
+				redacted = true
+			}
+		}
+	}
+	// re-encoding sorts the parameters, only do it when needed to keep the URL as scraped
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// embedComment adds a comment extension holding comment to the GIF file at path, before its trailer
+func embedComment(path, comment string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 || data[len(data)-1] != gifTrailer {
+		return errors.New("not a GIF file, missing the trailer")
+	}
+
+	// the comment is split in sub-blocks of at most 255 bytes, ended by an empty one
+	var ext bytes.Buffer
+	ext.Write([]byte{0x21, 0xFE})
+	text := []byte(comment)
+	for len(text) > 0 {
+		n := len(text)
+		if n > 255 {
+			n = 255
+		}
+		ext.WriteByte(byte(n))
+		ext.Write(text[:n])
+		text = text[n:]
+	}
+	ext.WriteByte(0)
+
+	out := append(data[:len(data)-1:len(data)-1], ext.Bytes()...)
+	out = append(out, gifTrailer)
+	return ioutil.WriteFile(path, out, 0644)
+}
@@ -0,0 +1,27 @@
+package gifhub
+
+import (
+	"context"
+	"image"
+	"math"
+
+	"github.com/golang/freetype/truetype"
+)
+
+// spinImgs returns the frames of the chart of act turning a full circle in the given number of frames
+// the upright chart is the frame that follows them, so they turn from one step past it to one step short of it
+// the baseline base turns along with the chart, unless it is nil
+// no frame is rendered once ctx is done
+func spinImgs(ctx context.Context, act activity, base *activity, frames int, l layout, s style, font *truetype.Font) ([]image.Image, error) {
+	s = withFonts(s, font)
+
+	imgs := make([]image.Image, frames)
+	for i := range imgs {
+		if err := stopped(ctx); err != nil {
+			return nil, err
+		}
+		g := graph{Data: act, Coords: coordinates(act, l), Baseline: baselineCoords(base, l), Rotation: 2 * math.Pi * float64(i+1) / float64(frames+1)}
+		imgs[i] = img(g, s)
+	}
+	return imgs, nil
+}
@@ -0,0 +1,58 @@
+package gifhub
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestSpinImgsSkipUpright(t *testing.T) {
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := withFonts(defaultStyle(), font)
+	act := sampleActivities[0]
+	l := layout{Width: defaultWidth}
+	upright := img(graph{Data: act, Coords: coordinates(act, l)}, s)
+
+	frames, err := spinImgs(context.Background(), act, nil, 4, l, s, font)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("expected 4 frames, got %d", len(frames))
+	}
+	for i, f := range frames {
+		if sameImage(f, upright) {
+			t.Errorf("frame %d: expected a turned chart, got the upright one that follows the intro", i)
+		}
+	}
+}
+
+func TestSpinNotBounced(t *testing.T) {
+	// a title frame, 3 intro frames and 5 years
+	imgs := make([]image.Image, 1+3+5)
+	metas := make([]frameMeta, len(imgs))
+	for i := range imgs {
+		imgs[i] = image.NewGray(image.Rect(0, 0, 1, 1))
+		metas[i] = frameMeta{Index: i}
+	}
+	imgs, metas = bounceFrom(imgs, metas, 1+3)
+
+	// the title and intro are played once, then the 5 years bounced back through the 3 in between
+	if len(imgs) != 1+3+5+3 {
+		t.Errorf("expected %d frames, got %d", 1+3+5+3, len(imgs))
+	}
+	got := make([]int, len(metas))
+	for i, m := range metas {
+		got[i] = m.Index
+	}
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 7, 6, 5}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected the frames %v, got %v", want, got)
+	}
+}
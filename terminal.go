@@ -0,0 +1,83 @@
+package gifhub
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// kittyChunkSize is the largest base64 payload of a single Kitty graphics escape sequence
+const kittyChunkSize = 4096
+
+// inlineProtocol returns the inline image protocol supported by the terminal described by the environment
+// iterm or kitty, empty if the terminal does not display images
+func inlineProtocol(getenv func(string) string) string {
+	switch {
+	case getenv("TERM_PROGRAM") == "iTerm.app" || getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm"
+	case getenv("KITTY_WINDOW_ID") != "" || getenv("TERM") == "xterm-kitty":
+		return "kitty"
+	}
+	return ""
+}
+
+// showInline displays the GIF in the terminal after the output of gifhub
+// Kitty does not play GIFs, it displays the final frame instead
+func showInline(w io.Writer, gifPath string, final image.Image) error {
+	switch inlineProtocol(os.Getenv) {
+	case "iterm":
+		data, err := ioutil.ReadFile(gifPath)
+		if err != nil {
+			return err
+		}
+		return itermImage(w, filepath.Base(gifPath), data)
+	case "kitty":
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, final); err != nil {
+			return err
+		}
+		return kittyImage(w, buf.Bytes())
+	}
+	return errors.New("the terminal does not support inline images, only iTerm2, WezTerm and Kitty do")
+}
+
+// itermImage writes the escape sequence of iTerm2's inline images protocol displaying data
+func itermImage(w io.Writer, name string, data []byte) error {
+	_, err := fmt.Fprintf(w, "\x1b]1337;File=name=%s;size=%d;inline=1:%s\a\n",
+		base64.StdEncoding.EncodeToString([]byte(name)), len(data), base64.StdEncoding.EncodeToString(data))
+	return err
+}
+
+// kittyImage writes the escape sequences of Kitty's graphics protocol displaying a PNG
+// the payload is split in chunks, all but the last one flagged with m=1
+func kittyImage(w io.Writer, pngData []byte) error {
+	payload := base64.StdEncoding.EncodeToString(pngData)
+	for first := true; first || payload != ""; first = false {
+		chunk := payload
+		if len(chunk) > kittyChunkSize {
+			chunk = chunk[:kittyChunkSize]
+		}
+		payload = payload[len(chunk):]
+
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		control := fmt.Sprintf("m=%d", more)
+		if first {
+			control = fmt.Sprintf("a=T,f=100,m=%d", more)
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", control, chunk); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
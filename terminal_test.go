@@ -0,0 +1,85 @@
+package gifhub
+
+import (
+	"bytes"
+	"encoding/base64"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestInlineProtocol(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, "iterm"},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, "iterm"},
+		{map[string]string{"KITTY_WINDOW_ID": "1"}, "kitty"},
+		{map[string]string{"TERM": "xterm-kitty"}, "kitty"},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "Apple_Terminal"}, ""},
+	} {
+		if got := inlineProtocol(func(key string) string { return tc.env[key] }); got != tc.want {
+			t.Errorf("%v: expected %q, got %q", tc.env, tc.want, got)
+		}
+	}
+}
+
+func TestItermImage(t *testing.T) {
+	data := []byte("GIF89a...")
+	var buf bytes.Buffer
+	if err := itermImage(&buf, "octocat.gif", data); err != nil {
+		t.Fatal(err)
+	}
+	want := "\x1b]1337;File=name=" + base64.StdEncoding.EncodeToString([]byte("octocat.gif")) +
+		";size=9;inline=1:" + base64.StdEncoding.EncodeToString(data) + "\a\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+// kittySequence matches an escape sequence of Kitty's graphics protocol
+var kittySequence = regexp.MustCompile("\x1b_G([^;]*);([A-Za-z0-9+/=]*)\x1b\\\\")
+
+func TestKittyImage(t *testing.T) {
+	for _, size := range []int{10, kittyChunkSize / 4 * 3, kittyChunkSize * 2} {
+		data := bytes.Repeat([]byte{0x89}, size)
+		var buf bytes.Buffer
+		if err := kittyImage(&buf, data); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		if !strings.HasSuffix(out, "\n") {
+			t.Errorf("%d bytes: expected a final line break", size)
+		}
+
+		var payload strings.Builder
+		seqs := kittySequence.FindAllStringSubmatch(out, -1)
+		if joined := len(strings.Join(kittySequence.FindAllString(out, -1), "")); joined != len(out)-1 {
+			t.Errorf("%d bytes: expected only escape sequences, got %q", size, out)
+		}
+		for i, seq := range seqs {
+			want := "m=1"
+			if i == len(seqs)-1 {
+				want = "m=0"
+			}
+			if i == 0 {
+				want = "a=T,f=100," + want
+			}
+			if seq[1] != want {
+				t.Errorf("%d bytes, chunk %d: expected the control %q, got %q", size, i, want, seq[1])
+			}
+			if len(seq[2]) > kittyChunkSize {
+				t.Errorf("%d bytes, chunk %d: expected at most %d bytes of payload, got %d", size, i, kittyChunkSize, len(seq[2]))
+			}
+			payload.WriteString(seq[2])
+		}
+		decoded, err := base64.StdEncoding.DecodeString(payload.String())
+		if err != nil || !bytes.Equal(decoded, data) {
+			t.Errorf("%d bytes: expected the chunks to join into the image, got %d bytes %v", size, len(decoded), err)
+		}
+		if wantChunks := (base64.StdEncoding.EncodedLen(size) + kittyChunkSize - 1) / kittyChunkSize; len(seqs) != wantChunks {
+			t.Errorf("%d bytes: expected %d chunks, got %d", size, wantChunks, len(seqs))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gifhub
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // avatars may be GIFs
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+)
+
+// themeClusters is the number of dominant colors searched for in an avatar
+const themeClusters = 3
+
+// themeMinColorful is the smallest share of colorful pixels an avatar needs to derive a theme from
+const themeMinColorful = 0.1
+
+// fetchAvatar returns the avatar of a GitHub user
+func fetchAvatar(handle string, opts fetchOptions) (image.Image, error) {
+	body, err := html(opts.webURL("/"+handle+".png"), opts)
+	if err != nil {
+		return nil, fmt.Errorf("fetch avatar: %v", err)
+	}
+	avatar, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("decode avatar: %v", err)
+	}
+	return avatar, nil
+}
+
+// themeFromImage returns the polygon and axis colors derived from the dominant color of the image
+// grey pixels are left out, an image with too few colorful pixels has no theme
+func themeFromImage(img image.Image) (poly, axis color.Color, err error) {
+	bounds := img.Bounds()
+	// a grid of about 64x64 samples is enough to find the dominant colors
+	step := bounds.Dx() / 64
+	if step < 1 {
+		step = 1
+	}
+
+	var pixels [][3]float64
+	total := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			total++
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < 0x8000 {
+				continue
+			}
+			p := [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+			if saturation(p) < 0.25 {
+				continue
+			}
+			pixels = append(pixels, p)
+		}
+	}
+	if total == 0 || float64(len(pixels))/float64(total) < themeMinColorful {
+		return nil, nil, errors.New("too little color variance")
+	}
+
+	centroids, counts := kmeans(pixels, themeClusters, 10)
+	dominant := 0
+	for i := range counts {
+		if counts[i] > counts[dominant] {
+			dominant = i
+		}
+	}
+
+	c := centroids[dominant]
+	poly = color.RGBA{uint8(c[0]), uint8(c[1]), uint8(c[2]), 0xff}
+	// as in the default style, the axis is a darker shade of the polygon
+	axis = color.RGBA{uint8(c[0] * 0.88), uint8(c[1] * 0.88), uint8(c[2] * 0.88), 0xff}
+	return poly, axis, nil
+}
+
+// saturation returns the HSV saturation of an RGB pixel
+func saturation(p [3]float64) float64 {
+	max := math.Max(p[0], math.Max(p[1], p[2]))
+	min := math.Min(p[0], math.Min(p[1], p[2]))
+	if max == 0 {
+		return 0
+	}
+	return (max - min) / max
+}
+
+// kmeans groups the pixels in k clusters, returning their centroids and sizes
+// the initial centroids are spread over the pixels in order, so the result does not vary between runs
+func kmeans(pixels [][3]float64, k, iterations int) ([][3]float64, []int) {
+	if len(pixels) < k {
+		k = len(pixels)
+	}
+	centroids := make([][3]float64, k)
+	for i := range centroids {
+		centroids[i] = pixels[i*len(pixels)/k]
+	}
+
+	counts := make([]int, k)
+	for it := 0; it < iterations; it++ {
+		sums := make([][3]float64, k)
+		counts = make([]int, k)
+		for _, p := range pixels {
+			closest, closestDist := 0, math.Inf(1)
+			for i, c := range centroids {
+				d := (p[0]-c[0])*(p[0]-c[0]) + (p[1]-c[1])*(p[1]-c[1]) + (p[2]-c[2])*(p[2]-c[2])
+				if d < closestDist {
+					closest, closestDist = i, d
+				}
+			}
+			for ch := range p {
+				sums[closest][ch] += p[ch]
+			}
+			counts[closest]++
+		}
+		for i := range centroids {
+			if counts[i] == 0 {
+				continue
+			}
+			for ch := range centroids[i] {
+				centroids[i][ch] = sums[i][ch] / float64(counts[i])
+			}
+		}
+	}
+	return centroids, counts
+}
+
+// profileTheme returns the polygon and axis colors derived from the avatar of a GitHub user
+func profileTheme(handle string, opts fetchOptions) (poly, axis color.Color, err error) {
+	avatar, err := fetchAvatar(handle, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return themeFromImage(avatar)
+}
@@ -0,0 +1,69 @@
+package gifhub
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// avatarFixture is a 64x64 avatar, mostly red with a blue band and a white border
+func avatarFixture() image.Image {
+	m := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(m, m.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(m, image.Rect(4, 4, 60, 40), image.NewUniform(color.RGBA{200, 30, 30, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(m, image.Rect(4, 40, 60, 60), image.NewUniform(color.RGBA{20, 40, 200, 0xff}), image.Point{}, draw.Src)
+	return m
+}
+
+func TestThemeFromImage(t *testing.T) {
+	poly, axis, err := themeFromImage(avatarFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (color.RGBA{200, 30, 30, 0xff}); poly != want {
+		t.Errorf("expected the dominant red %v, got %v", want, poly)
+	}
+	if want := (color.RGBA{176, 26, 26, 0xff}); axis != want {
+		t.Errorf("expected a darker red %v, got %v", want, axis)
+	}
+
+	// a grey avatar, such as the default identicon background, has no theme
+	grey := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	draw.Draw(grey, grey.Bounds(), image.NewUniform(color.RGBA{0xf0, 0xf0, 0xf0, 0xff}), image.Point{}, draw.Src)
+	draw.Draw(grey, image.Rect(0, 0, 4, 4), image.NewUniform(color.RGBA{200, 30, 30, 0xff}), image.Point{}, draw.Src)
+	if _, _, err := themeFromImage(grey); err == nil {
+		t.Error("expected too little color variance")
+	}
+}
+
+func TestProfileTheme(t *testing.T) {
+	var avatar bytes.Buffer
+	if err := png.Encode(&avatar, avatarFixture()); err != nil {
+		t.Fatal(err)
+	}
+	stubGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/octocat.png":
+			w.Write(avatar.Bytes())
+		case "/broken.png":
+			w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	opts := fetchOptions{MaxBytes: 1 << 20}
+	if poly, _, err := profileTheme("octocat", opts); err != nil || poly != (color.RGBA{200, 30, 30, 0xff}) {
+		t.Errorf("expected the theme of the avatar, got %v %v", poly, err)
+	}
+	// the callers fall back to the default theme on these errors
+	for _, handle := range []string{"broken", "ghost"} {
+		if _, _, err := profileTheme(handle, opts); err == nil {
+			t.Errorf("%s: expected an error", handle)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package gifhub
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+)
+
+// titleSize is the size of the font of the title frame on the default 500px wide canvas, twice that of the labels
+const titleSize = 48
+
+// titleImg returns a frame of the given size with the handle centered on the background of the graphs,
+// in the font and color of their labels. A handle too wide for the frame is drawn smaller
+func titleImg(handle string, size image.Rectangle, s style, font *truetype.Font) image.Image {
+	w, h := float64(size.Dx()), float64(size.Dy())
+
+	dc := gg.NewContext(size.Dx(), size.Dy())
+	if !s.Transparent {
+		dc.SetColor(color.White)
+		dc.Clear()
+	}
+	if s.BgGradient != nil {
+		gradient := gg.NewLinearGradient(0, 0, 0, h)
+		gradient.AddColorStop(0, s.BgGradient[0])
+		gradient.AddColorStop(1, s.BgGradient[1])
+		dc.SetFillStyle(gradient)
+		dc.DrawRectangle(0, 0, w, h)
+		dc.Fill()
+	}
+
+	points := titleSize * s.Scale
+	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: points}))
+	if textW, _ := dc.MeasureString(handle); textW > 0.9*w {
+		dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: points * 0.9 * w / textW}))
+	}
+	dc.SetColor(s.LabelColor)
+	dc.DrawStringAnchored(handle, w/2, h/2, 0.5, 0.5)
+	return dc.Image()
+}
@@ -0,0 +1,48 @@
+package gifhub
+
+import (
+	"context"
+	"errors"
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// webpLoop converts a GIF loop count into the WebP one: GIF counts the restarts after the first play, -1 to play once,
+// while WebP counts the plays, 0 to loop forever
+func webpLoop(loop int) uint16 {
+	switch {
+	case loop == 0:
+		return 0
+	case loop < 0:
+		return 1
+	}
+	return uint16(loop + 1)
+}
+
+// encodeWebP bundles the frames into an animated WebP written to w, it is an encoder as encodeGIF
+// the frames keep all their colors, so opts.Palette and opts.Compact do not apply
+func encodeWebP(ctx context.Context, w io.Writer, frames []image.Image, opts gifOptions) error {
+	switch {
+	case len(frames) == 0:
+		return errors.New("WebP: no images to bundle")
+	case opts.Delay == 0 && len(frames) > 1:
+		return errors.New("WebP: no transition delay given, a delay of 0 is only valid for a single frame")
+	}
+
+	// WebP durations are in milliseconds, GIF delays in hundredths of a second
+	durations := make([]uint, len(frames))
+	disposals := make([]uint, len(frames))
+	for i := range frames {
+		durations[i] = uint(opts.Delay) * 10
+		if opts.Transparent {
+			disposals[i] = 1 // dispose to the background, so that a frame does not show through the next
+		}
+	}
+	durations[0] = uint(opts.FirstDelay) * 10
+	durations[len(frames)-1] = uint(opts.FinalDelay) * 10
+
+	anim := nativewebp.Animation{Images: frames, Durations: durations, Disposals: disposals, LoopCount: webpLoop(opts.Loop)}
+	return nativewebp.EncodeAll(contextWriter{ctx, w}, &anim, nil)
+}
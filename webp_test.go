@@ -0,0 +1,35 @@
+package gifhub
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestWebpLoop(t *testing.T) {
+	for loop, want := range map[int]uint16{0: 0, -1: 1, 1: 2, 3: 4} {
+		if got := webpLoop(loop); got != want {
+			t.Errorf("GIF loop %d: expected the WebP loop %d, got %d", loop, want, got)
+		}
+	}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	frames := sampleFrames(t, 0)
+	var buf bytes.Buffer
+	if err := encodeWebP(context.Background(), &buf, frames, gifOptions{FirstDelay: 40, Delay: 40, FinalDelay: 300}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		t.Fatalf("expected a WebP file, got % x", data[:12])
+	}
+	if n := bytes.Count(data, []byte("ANMF")); n != len(frames) {
+		t.Errorf("expected %d animation frames, got %d", len(frames), n)
+	}
+
+	buf.Reset()
+	if err := encodeWebP(context.Background(), &buf, frames, gifOptions{}); err == nil || buf.Len() > 0 {
+		t.Errorf("expected several frames without a delay to fail before writing, got %v and %d bytes", err, buf.Len())
+	}
+}